@@ -0,0 +1,162 @@
+package limiter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTrustedHeader is the forwarding header honored by default.
+const DefaultTrustedHeader = "X-Forwarded-For"
+
+var (
+	// DefaultIPv4Mask keeps the whole IPv4 address.
+	DefaultIPv4Mask = net.CIDRMask(32, 32)
+	// DefaultIPv6Mask keeps the /64 network of IPv6 addresses, usually assigned to a single client
+	// which can rotate through its addresses.
+	DefaultIPv6Mask = net.CIDRMask(64, 128)
+)
+
+// ParseNetworks parses a list of CIDRs, such as "10.0.0.0/8", or single IP addresses.
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, errors.Errorf("invalid IP address %q", value)
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid CIDR %q", value)
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// GetClientIP returns the IP address of the client. The trusted header, X-Forwarded-For if empty, is
+// only honored when the peer is a trusted proxy: its hops are walked from right to left, and the first
+// address which is not a trusted proxy is the client. Other forwarding headers are ignored, since
+// proxies pass through the headers they don't set, as written by the client.
+func GetClientIP(r *http.Request, trustedProxies []*net.IPNet, trustedHeader string) net.IP {
+	ip := GetIP(r)
+	if ip == nil || !isTrustedProxy(ip, trustedProxies) {
+		return ip
+	}
+
+	hops := getForwardedHops(r, trustedHeader)
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := parseHop(hops[i])
+		if hop == nil {
+			// Hops left of an unknown or malformed one can't be trusted.
+			return ip
+		}
+
+		ip = hop
+		if !isTrustedProxy(ip, trustedProxies) {
+			return ip
+		}
+	}
+
+	return ip
+}
+
+// MaskIP returns the network of the IP address, given the masks for IPv4 and IPv6 addresses.
+// A nil mask keeps the address unchanged.
+func MaskIP(ip net.IP, ipv4Mask net.IPMask, ipv6Mask net.IPMask) net.IP {
+	if ip == nil {
+		return nil
+	}
+
+	if ip4 := ip.To4(); ip4 != nil {
+		if ipv4Mask == nil {
+			return ip4
+		}
+		return ip4.Mask(ipv4Mask)
+	}
+
+	if ipv6Mask == nil {
+		return ip
+	}
+	return ip.Mask(ipv6Mask)
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// getForwardedHops returns the addresses of the proxy chain, from the client to the peer, read from
+// given header: an RFC 7239 Forwarded header, or a comma-separated list of addresses such as
+// X-Forwarded-For and X-Real-IP.
+func getForwardedHops(r *http.Request, header string) []string {
+	if header == "" {
+		header = DefaultTrustedHeader
+	}
+
+	values := r.Header.Values(header)
+	if strings.EqualFold(header, "Forwarded") {
+		return getForwardedFor(values)
+	}
+
+	var hops []string
+	for _, value := range values {
+		hops = append(hops, strings.Split(value, ",")...)
+	}
+	return hops
+}
+
+// getForwardedFor returns the "for" parameter of each element of RFC 7239 Forwarded headers.
+// An element without it is returned as an empty hop.
+func getForwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			hop := ""
+			for _, pair := range strings.Split(element, ";") {
+				name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(name, "for") {
+					hop = strings.Trim(value, `"`)
+				}
+			}
+			hops = append(hops, hop)
+		}
+	}
+	return hops
+}
+
+// parseHop parses an address of a forwarding header, optionally with a port and, for IPv6,
+// between brackets. It returns nil for obfuscated identifiers, "unknown" and malformed values.
+func parseHop(hop string) net.IP {
+	hop = strings.TrimSpace(hop)
+	if ip := net.ParseIP(hop); ip != nil {
+		return ip
+	}
+
+	host, _, err := net.SplitHostPort(hop)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(hop, "["), "]")
+	}
+
+	return net.ParseIP(host)
+}
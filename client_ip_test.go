@@ -0,0 +1,135 @@
+package limiter_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+func TestGetClientIP(t *testing.T) {
+	is := require.New(t)
+
+	trustedProxies, err := limiter.ParseNetworks([]string{"10.0.0.0/8", "2001:db8::1"})
+	is.NoError(err)
+	is.Len(trustedProxies, 2)
+
+	_, err = limiter.ParseNetworks([]string{"10.0.0.0/33"})
+	is.Error(err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "203.0.113.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "203.0.113.1",
+		},
+		{
+			name:       "trusted peer without headers",
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "spoofed x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1, 10.0.0.2"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "malformed x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1, garbage, 10.0.0.2"},
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "x-real-ip",
+			remoteAddr: "[2001:db8::1]:1234",
+			header:     "X-Real-IP",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "spoofed forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				"Forwarded":       "for=203.0.113.66",
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			expected: "198.51.100.1",
+		},
+		{
+			name:       "x-real-ip without trusted header",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.66"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "Forwarded",
+			headers: map[string]string{
+				"Forwarded":       `for=192.0.2.1, for="[2001:db8:cafe::17]:4711";proto=https, for=10.0.0.2`,
+				"X-Forwarded-For": "198.51.100.1",
+			},
+			expected: "2001:db8:cafe::17",
+		},
+		{
+			name:       "obfuscated forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			header:     "Forwarded",
+			headers:    map[string]string{"Forwarded": "for=192.0.2.1, for=_hidden"},
+			expected:   "10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest("GET", "/", nil)
+			require.NoError(t, err)
+			request.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				request.Header.Set(name, value)
+			}
+
+			require.Equal(t, tt.expected, limiter.GetClientIP(request, trustedProxies, tt.header).String())
+		})
+	}
+}
+
+func TestGetIPWithPrefix(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+
+	full := limiter.NewLimiter(nil, limiter.Rate{}, limiter.WithIPv6Prefix(128))
+	masked := limiter.NewLimiter(nil, limiter.Rate{}, limiter.WithIPv4Prefix(24), limiter.WithIPv6Prefix(64))
+	byDefault := limiter.NewLimiter(nil, limiter.Rate{})
+
+	request.RemoteAddr = "192.168.0.42:8080"
+	is.Equal("192.168.0.42", full.GetIP(request).String())
+	is.Equal("192.168.0.0", masked.GetIP(request).String())
+	is.Equal("192.168.0.42", byDefault.GetIP(request).String())
+
+	request.RemoteAddr = "[2001:db8:cafe:1:a:b:c:d]:8080"
+	is.Equal("2001:db8:cafe:1:a:b:c:d", full.GetIP(request).String())
+	is.Equal("2001:db8:cafe:1::", masked.GetIP(request).String())
+	is.Equal("2001:db8:cafe:1::", byDefault.GetIP(request).String())
+
+	request.RemoteAddr = "[2001:db8:cafe:1:ffff:ffff:ffff:ffff]:8080"
+	is.Equal("2001:db8:cafe:1::", masked.GetIP(request).String())
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	stdlib "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+)
+
+const usage = `Usage: admin [flags] <command> [arguments]
+
+Commands:
+  list [count]     lists every key, by batches of count keys
+  top [n]          lists the n keys with the highest count, 10 by default
+  blocked          lists the keys which are currently blocked
+  get KEY          returns the state of the key
+  reset KEY        resets the key, unblocking it
+  grant KEY N      gives back up to N consumed requests to the key
+
+Flags:
+`
+
+var errUsage = errors.New("invalid usage")
+
+type client struct {
+	addr   string
+	token  string
+	output string
+	http   *http.Client
+}
+
+type keysResponse struct {
+	Keys   []stdlib.AdminKeyState `json:"keys"`
+	Cursor *uint64                `json:"cursor"`
+}
+
+func main() {
+	flags := pflag.NewFlagSet("admin", pflag.ContinueOnError)
+	addr := flags.String("addr", "http://localhost:9090", "address of the admin API")
+	token := flags.String("token", os.Getenv("ADMIN_TOKEN"), "bearer token of the admin API, ADMIN_TOKEN by default")
+	output := flags.StringP("output", "o", "table", "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(os.Args[1:])
+	if err != nil {
+		os.Exit(2)
+	}
+
+	c := &client{
+		addr:   *addr,
+		token:  *token,
+		output: *output,
+		http:   &http.Client{Timeout: 30 * time.Second},
+	}
+
+	err = c.run(flags.Args())
+	if err == errUsage {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "admin:", err)
+		os.Exit(1)
+	}
+}
+
+func (c *client) run(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	command, args := args[0], args[1:]
+	switch {
+	case command == "list" && len(args) <= 1:
+		return c.list(args)
+	case command == "top" && len(args) <= 1:
+		query := url.Values{}
+		if len(args) == 1 {
+			query.Set("n", args[0])
+		}
+		return c.keys("/keys/top?" + query.Encode())
+	case command == "blocked" && len(args) == 0:
+		return c.keys("/keys/blocked")
+	case command == "get" && len(args) == 1:
+		return c.context(http.MethodGet, "/keys/"+url.PathEscape(args[0]), nil)
+	case command == "reset" && len(args) == 1:
+		return c.context(http.MethodDelete, "/keys/"+url.PathEscape(args[0]), nil)
+	case command == "grant" && len(args) == 2:
+		count, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid count %q", args[1])
+		}
+		body, _ := json.Marshal(map[string]int64{"count": count})
+		return c.context(http.MethodPost, "/keys/"+url.PathEscape(args[0])+"/grant", body)
+	}
+	return errUsage
+}
+
+// list follows the cursor of the admin API until every key has been listed.
+func (c *client) list(args []string) error {
+	query := url.Values{}
+	if len(args) == 1 {
+		query.Set("count", args[0])
+	}
+
+	var keys []stdlib.AdminKeyState
+	for {
+		var response keysResponse
+		err := c.do(http.MethodGet, "/keys?"+query.Encode(), nil, &response)
+		if err != nil {
+			return err
+		}
+
+		keys = append(keys, response.Keys...)
+		if response.Cursor == nil || *response.Cursor == 0 {
+			break
+		}
+		query.Set("cursor", strconv.FormatUint(*response.Cursor, 10))
+	}
+
+	return c.printKeys(keys)
+}
+
+func (c *client) keys(path string) error {
+	var response keysResponse
+	err := c.do(http.MethodGet, path, nil, &response)
+	if err != nil {
+		return err
+	}
+	return c.printKeys(response.Keys)
+}
+
+func (c *client) context(method string, path string, body []byte) error {
+	var lctx stdlib.AdminContext
+	err := c.do(method, path, body, &lctx)
+	if err != nil {
+		return err
+	}
+
+	if c.output == "json" {
+		return printJSON(lctx)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tLIMIT\tREMAINING\tRESET\tREACHED\tBLOCKED UNTIL")
+	fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%t\t%s\n", lctx.Key, lctx.Limit, lctx.Remaining,
+		formatTime(lctx.Reset), lctx.Reached, formatTime(lctx.BlockedUntil))
+	return w.Flush()
+}
+
+func (c *client) printKeys(keys []stdlib.AdminKeyState) error {
+	if c.output == "json" {
+		return printJSON(keys)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tCOUNT\tRESET\tBLOCKED UNTIL")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", key.Key, key.Count, formatTime(key.Reset), formatTime(key.BlockedUntil))
+	}
+	return w.Flush()
+}
+
+func (c *client) do(method string, path string, body []byte, value interface{}) error {
+	request, err := http.NewRequest(method, c.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+c.token)
+
+	response, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	if response.StatusCode != http.StatusOK {
+		var adminError struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &adminError) == nil && adminError.Error != "" {
+			return fmt.Errorf("%s: %s", response.Status, adminError.Error)
+		}
+		return fmt.Errorf("%s", response.Status)
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func formatTime(unix int64) string {
+	if unix == 0 {
+		return "-"
+	}
+	return time.Unix(unix, 0).Format(time.RFC3339)
+}
@@ -1,34 +1,51 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
 	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
 	mhttp "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
 	stdlib "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+	fregistry "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/file"
+	rregistry "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/redis"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/breaker"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/hybrid"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
 	sredis "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/redis"
 	libredis "github.com/redis/go-redis/v9"
 )
 
+// configPath is the directory of the .env file.
+//
+//	const configPath = "./deployments/docker-compose" // <- Use em tempo de execução
+const configPath = "." // <- Use para debug | docker
+
 func main() {
-	//cfg, err := config.Load("./deployments/docker-compose") // <- Use em tempo de execução
-	cfg, err := config.Load(".") // <- Use para debug | docker
+	cfg, err := config.Load(configPath, os.Args[1:]...)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+		return
 	}
 
-	//redisUrl := "redis://rate-limiter-redis:6379/0"
-	redisUrl := fmt.Sprintf("redis://%v:%v/%v", cfg.RedisHost, cfg.RedisPort, cfg.RedisDB)
-	option, err := libredis.ParseURL(redisUrl)
+	client, err := newRedisClient(cfg)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 
-	client := libredis.NewClient(option)
+	// Fails on startup with wrong credentials, instead of on each request.
+	err = client.Ping(context.Background()).Err()
+	if err != nil {
+		log.Fatal(fmt.Errorf("failed to connect to redis: %w", err))
+		return
+	}
 
 	store, err := sredis.NewStoreWithOptions(client, limiter.StoreOptions{
 		Prefix: "limiter_http_example",
@@ -38,29 +55,97 @@ func main() {
 		return
 	}
 
-	rateByIP := limiter.NewRate(int64(cfg.RateMaxRequestsByIP), cfg.RatePeriodWindowSeconds)
-	rateByToken := limiter.NewRate(int64(cfg.RateMaxRequestsByToken), cfg.RatePeriodWindowSeconds)
+	rateByIP, err := cfg.GetRateByIP()
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	trustedProxies, err := limiter.ParseNetworks(strings.Split(cfg.TrustedProxies, ","))
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	limiterOptions := []limiter.Option{
+		limiter.WithTrustedProxies(trustedProxies...),
+		limiter.WithTrustedHeader(cfg.TrustedHeader),
+		limiter.WithIPv4Prefix(cfg.IPv4Prefix),
+		limiter.WithIPv6Prefix(cfg.IPv6Prefix),
+	}
+
+	// Fails fast while Redis is down, instead of waiting for each call to time out.
+	store = breaker.NewStore(store)
+	if cfg.RateSyncIntervalMs > 0 {
+		store = hybrid.NewStoreWithInterval(store, time.Duration(cfg.RateSyncIntervalMs)*time.Millisecond)
+	}
+
+	limiter := limiter.NewLimiter(store, rateByIP, limiterOptions...)
+
+	registry, err := newTokenRegistry(cfg, client)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	rules, err := newRules(cfg, limiter)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+
+	options := []stdlib.Option{
+		stdlib.WithRules(rules...),
+		stdlib.WithTokenRegistry(registry),
+	}
+	if cfg.RateHeaderStyle != "" {
+		options = append(options, stdlib.WithHeaderStyle(stdlib.HeaderStyle(cfg.RateHeaderStyle)))
+	}
+	switch stdlib.FailurePolicy(cfg.RateFailurePolicy) {
+	case stdlib.FailFallback:
+		options = append(options, stdlib.WithFallback(memory.NewStore()))
+	case stdlib.FailOpen, stdlib.FailClosed:
+		options = append(options, stdlib.WithFailurePolicy(stdlib.FailurePolicy(cfg.RateFailurePolicy)))
+	}
+	if cfg.RateStoreTimeoutMs > 0 {
+		options = append(options, stdlib.WithStoreTimeout(time.Duration(cfg.RateStoreTimeoutMs)*time.Millisecond))
+	}
 
-	limiterByIP := limiter.NewLimiter(store, rateByIP)
-	limiterByToken := limiter.NewLimiter(store, rateByToken)
+	middleware := mhttp.NewMiddleware(limiter, options...)
 
-	middlewareByIP := mhttp.NewMiddleware(
-		limiterByIP,
-		stdlib.WithKeyGetter(stdlib.WithIPKeyGetter(limiterByIP)),
-	)
+	err = watchConfig(configPath, cfg, middleware, registry)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
 
-	middlewareByToken := mhttp.NewMiddleware(
-		limiterByToken,
-		stdlib.WithKeyGetter(stdlib.WithTokenKeyGetter(limiterByToken)),
-	)
+	// The admin API is served on its own port, so it can be kept off the public network.
+	if cfg.AdminPort > 0 {
+		admin := stdlib.NewAdminHandler(middleware, cfg.AdminToken)
+		go func() {
+			log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.AdminPort), admin))
+		}()
+	}
 
-	http.Handle("/", middlewareByToken.Handler(middlewareByIP.Handler(http.HandlerFunc(index))))
-	//http.Handle("/", middlewareByIP.Handler(middlewareByToken.Handler(http.HandlerFunc(index))))
+	http.Handle("/", middleware.Handler(http.HandlerFunc(index)))
 	fmt.Println(fmt.Sprintf("Server is running on port %d...", cfg.AppPort))
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", cfg.AppPort), nil))
 
 }
 
+func newTokenRegistry(cfg *config.Config, client libredis.UniversalClient) (limiter.TokenRegistry, error) {
+	switch cfg.TokenRegistry {
+	case "file":
+		return fregistry.NewRegistry(cfg.TokenRegistryFile)
+	case "redis":
+		return rregistry.NewRegistry(client, "limiter_http_example:tokens"), nil
+	case "":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unknown token registry %q", cfg.TokenRegistry)
+}
+
 func index(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	_, err := w.Write([]byte(`{"message": "ok"}`))
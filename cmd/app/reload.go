@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
+	stdlib "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+	fregistry "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/file"
+)
+
+// watchConfig reloads the rates and the rules of the middleware, and the token registry file, when the
+// .env file or the rules file changes, or on SIGHUP. The other settings require a restart. The watched
+// paths are the ones of the startup configuration: a rules file set by a reload is read, but only
+// watched after a restart, and the path of the token registry file is kept.
+func watchConfig(path string, cfg *config.Config, middleware *stdlib.Middleware,
+	registry limiter.TokenRegistry) error {
+
+	paths := []string{filepath.Join(path, ".env")}
+	if cfg.RateRulesFile != "" {
+		paths = append(paths, cfg.RateRulesFile)
+	}
+	if registry, ok := registry.(*fregistry.Registry); ok {
+		paths = append(paths, registry.Path)
+	}
+
+	return config.Watch(context.Background(), paths, func() {
+		reloadConfig(path, middleware, registry)
+	})
+}
+
+// reloadConfig swaps the rate and the rules of the middleware for the ones of the current
+// configuration. The current ones are kept if the configuration is invalid.
+func reloadConfig(path string, middleware *stdlib.Middleware, registry limiter.TokenRegistry) {
+	if registry, ok := registry.(*fregistry.Registry); ok {
+		err := registry.Reload()
+		if err != nil {
+			log.Printf("failed to reload token registry, keeping the current one: %v", err)
+		}
+	}
+
+	cfg, err := config.Load(path, os.Args[1:]...)
+	if err != nil {
+		log.Printf("failed to reload config, keeping the current one: %v", err)
+		return
+	}
+
+	rateByIP, err := cfg.GetRateByIP()
+	if err != nil {
+		log.Printf("failed to reload config, keeping the current one: %v", err)
+		return
+	}
+
+	rules, err := newRules(cfg, middleware.GetLimiter())
+	if err != nil {
+		log.Printf("failed to reload config, keeping the current one: %v", err)
+		return
+	}
+
+	// The rules are swapped even if their rates are unchanged, as the requests they match may have changed.
+	// The rate of the limiter is swapped together with them, as the rules inherit its algorithm.
+	changes := diffRules(middleware.GetRules(), rules)
+	middleware.SetRatesAndRules(rules, rateByIP)
+
+	if len(changes) == 0 {
+		log.Printf("config reloaded, rates unchanged")
+	}
+	for _, change := range changes {
+		log.Printf("config reloaded: %s", change)
+	}
+}
+
+// diffRules describes the rules added, removed or whose rates changed.
+func diffRules(old []stdlib.Rule, new []stdlib.Rule) []string {
+	rates := make(map[string]string, len(old))
+	for _, rule := range old {
+		rates[rule.Name] = describeRates(rule)
+	}
+
+	changes := []string{}
+	for _, rule := range new {
+		before, ok := rates[rule.Name]
+		after := describeRates(rule)
+		switch {
+		case !ok:
+			changes = append(changes, fmt.Sprintf("rule %q added: %s", rule.Name, after))
+		case before != after:
+			changes = append(changes, fmt.Sprintf("rule %q changed: %s -> %s", rule.Name, before, after))
+		}
+		delete(rates, rule.Name)
+	}
+
+	for _, rule := range old {
+		if _, ok := rates[rule.Name]; ok {
+			changes = append(changes, fmt.Sprintf("rule %q removed", rule.Name))
+		}
+	}
+
+	return changes
+}
+
+func describeRates(rule stdlib.Rule) string {
+	rates := []string{rule.Rate.String()}
+	if rule.Rate.Algorithm != "" {
+		rates[0] += " " + string(rule.Rate.Algorithm)
+	}
+	for _, rate := range rule.Rates {
+		rates = append(rates, rate.String())
+	}
+	return strings.Join(rates, ", ")
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
+	stdlib "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+)
+
+// newRules returns the rules of the configuration: the rules of the rules file, if any, followed by
+// the default rules by token and by IP.
+func newRules(cfg *config.Config, l *limiter.Limiter) ([]stdlib.Rule, error) {
+	rateByIP, err := cfg.GetRateByIP()
+	if err != nil {
+		return nil, err
+	}
+
+	rateByToken, err := cfg.GetRateByToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// The quota is enforced together with the rate of each token.
+	ratesByToken := []limiter.Rate{}
+	if cfg.RateQuotaByToken > 0 {
+		quota, err := cfg.GetQuotaRate()
+		if err != nil {
+			return nil, err
+		}
+		ratesByToken = append(ratesByToken, quota)
+	}
+
+	// Token overrides IP: requests carrying an API_KEY are only limited by token.
+	rules := []stdlib.Rule{
+		stdlib.NewRule("token", stdlib.WithTokenKeyGetter(l), rateByToken, ratesByToken...),
+		stdlib.NewRule("ip", stdlib.WithIPKeyGetter(l), rateByIP),
+	}
+	if cfg.RateRulesFile == "" {
+		return rules, nil
+	}
+
+	file, err := config.LoadRules(cfg.RateRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Requests matched by no rule of the file fall back to the default rules.
+	return append(compileRules(file, l), rules...), nil
+}
+
+// compileRules compiles the rules of the rules file into middleware rules.
+func compileRules(file *config.RulesFile, l *limiter.Limiter) []stdlib.Rule {
+	rules := make([]stdlib.Rule, 0, len(file.Rules))
+	for _, rule := range file.Rules {
+		// The rules file is validated when loaded.
+		rate, rates, _ := rule.GetRates()
+		rules = append(rules, stdlib.NewRule(rule.Name, newRuleKeyGetter(rule, l), rate, rates...))
+	}
+	return rules
+}
+
+func newRuleKeyGetter(rule config.Rule, l *limiter.Limiter) stdlib.KeyGetter {
+	var keyGetter stdlib.KeyGetter
+	switch {
+	case rule.Key == config.KeyIP:
+		keyGetter = stdlib.WithClientIPKeyGetter(l)
+	case rule.Key == config.KeyToken:
+		keyGetter = stdlib.WithTokenKeyGetter(l)
+	case rule.Key == config.KeyTokenOrIP:
+		keyGetter = stdlib.WithTokenAndIPKeyGetter(l)
+	case rule.Key == config.KeyGlobal:
+		keyGetter = stdlib.WithGlobalKeyGetter()
+	default:
+		keyGetter = stdlib.WithHeaderKeyGetter(strings.TrimPrefix(rule.Key, config.KeyHeaderPrefix))
+	}
+
+	matchers := []stdlib.RequestMatcher{}
+	if rule.Path != "" {
+		matchers = append(matchers, stdlib.MatchPath(rule.Path))
+	}
+	if len(rule.Methods) > 0 {
+		matchers = append(matchers, stdlib.MatchMethods(rule.Methods...))
+	}
+	if rule.Host != "" {
+		matchers = append(matchers, stdlib.MatchHost(rule.Host))
+	}
+	for name, value := range rule.Headers {
+		matchers = append(matchers, stdlib.MatchHeader(name, value))
+	}
+
+	return stdlib.WithMatchers(keyGetter, matchers...)
+}
@@ -0,0 +1,72 @@
+package limiter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// DefaultLeaseTTL is the duration after which a lease which was neither renewed nor released expires.
+const DefaultLeaseTTL = 1 * time.Minute
+
+// Lease is one of the slots of a key, held by an in-flight request.
+type Lease struct {
+	Key string
+	ID  string
+	// Acquired is false when the key already holds its limit of leases.
+	Acquired bool
+	Limit    int64
+	// InFlight is the number of leases held by the key, including this one when acquired.
+	InFlight  int64
+	ExpiresAt time.Time
+}
+
+// ConcurrencyStore keeps the leases of each key. Expired leases are not counted, so that the slots
+// of crashed holders are eventually freed.
+type ConcurrencyStore interface {
+	// Acquire adds a lease to the key, unless it already holds limit leases.
+	Acquire(ctx context.Context, key string, limit int64, ttl time.Duration) (Lease, error)
+	// Renew extends the lease by ttl. The returned lease is not acquired anymore if it had expired.
+	Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error)
+	// Release frees the slot of the lease.
+	Release(ctx context.Context, lease Lease) error
+}
+
+// ConcurrencyLimiter caps the number of simultaneous requests of each key.
+type ConcurrencyLimiter struct {
+	Store ConcurrencyStore
+	Limit int64
+	TTL   time.Duration
+}
+
+func NewConcurrencyLimiter(store ConcurrencyStore, limit int64, ttl time.Duration) *ConcurrencyLimiter {
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL
+	}
+
+	return &ConcurrencyLimiter{
+		Store: store,
+		Limit: limit,
+		TTL:   ttl,
+	}
+}
+
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context, key string) (Lease, error) {
+	return l.Store.Acquire(ctx, key, l.Limit, l.TTL)
+}
+
+func (l *ConcurrencyLimiter) Renew(ctx context.Context, lease Lease) (Lease, error) {
+	return l.Store.Renew(ctx, lease, l.TTL)
+}
+
+func (l *ConcurrencyLimiter) Release(ctx context.Context, lease Lease) error {
+	return l.Store.Release(ctx, lease)
+}
+
+// NewLeaseID returns a random identifier for a lease.
+func NewLeaseID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -1,34 +1,171 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
 
 type Config struct {
-	AppPort                 int    `mapstructure:"APP_PORT"`
-	RedisHost               string `mapstructure:"REDIS_HOST"`
-	RedisPort               int    `mapstructure:"REDIS_PORT"`
-	RedisPassword           string `mapstructure:"REDIS_PASSWORD"`
-	RedisDB                 int    `mapstructure:"REDIS_DB"`
-	RateMaxRequestsByIP     int    `mapstructure:"RATE_MAX_REQUESTS_BY_IP"`
-	RateMaxRequestsByToken  int    `mapstructure:"RATE_MAX_REQUESTS_BY_TOKEN"`
-	RatePeriodWindowSeconds int    `mapstructure:"RATE_PERIOD_WINDOW_SECONDS"`
+	AppPort                    int    `mapstructure:"APP_PORT"`
+	AdminPort                  int    `mapstructure:"ADMIN_PORT"`
+	AdminToken                 string `mapstructure:"ADMIN_TOKEN"`
+	RedisURL                   string `mapstructure:"REDIS_URL"`
+	RedisHost                  string `mapstructure:"REDIS_HOST"`
+	RedisPort                  int    `mapstructure:"REDIS_PORT"`
+	RedisUsername              string `mapstructure:"REDIS_USERNAME"`
+	RedisPassword              string `mapstructure:"REDIS_PASSWORD"`
+	RedisDB                    int    `mapstructure:"REDIS_DB"`
+	RedisMode                  string `mapstructure:"REDIS_MODE"`
+	RedisAddrs                 string `mapstructure:"REDIS_ADDRS"`
+	RedisMasterName            string `mapstructure:"REDIS_MASTER_NAME"`
+	RedisTLS                   bool   `mapstructure:"REDIS_TLS"`
+	RedisTLSCAFile             string `mapstructure:"REDIS_TLS_CA_FILE"`
+	RedisTLSCertFile           string `mapstructure:"REDIS_TLS_CERT_FILE"`
+	RedisTLSKeyFile            string `mapstructure:"REDIS_TLS_KEY_FILE"`
+	RedisTLSInsecureSkipVerify bool   `mapstructure:"REDIS_TLS_INSECURE_SKIP_VERIFY"`
+	RedisPoolSize              int    `mapstructure:"REDIS_POOL_SIZE"`
+	RedisMinIdleConns          int    `mapstructure:"REDIS_MIN_IDLE_CONNS"`
+	RateByIP                   string `mapstructure:"RATE_BY_IP"`
+	RateByToken                string `mapstructure:"RATE_BY_TOKEN"`
+	RateMaxRequestsByIP        int    `mapstructure:"RATE_MAX_REQUESTS_BY_IP"`
+	RateMaxRequestsByToken     int    `mapstructure:"RATE_MAX_REQUESTS_BY_TOKEN"`
+	RatePeriodWindowSeconds    int    `mapstructure:"RATE_PERIOD_WINDOW_SECONDS"`
+	TokenRegistry              string `mapstructure:"TOKEN_REGISTRY"`
+	TokenRegistryFile          string `mapstructure:"TOKEN_REGISTRY_FILE"`
+	RateHeaderStyle            string `mapstructure:"RATE_HEADER_STYLE"`
+	TrustedProxies             string `mapstructure:"TRUSTED_PROXIES"`
+	TrustedHeader              string `mapstructure:"TRUSTED_HEADER"`
+	IPv4Prefix                 int    `mapstructure:"IPV4_PREFIX"`
+	IPv6Prefix                 int    `mapstructure:"IPV6_PREFIX"`
+	RateFailurePolicy          string `mapstructure:"RATE_FAILURE_POLICY"`
+	RateStoreTimeoutMs         int    `mapstructure:"RATE_STORE_TIMEOUT_MS"`
+	RateSyncIntervalMs         int    `mapstructure:"RATE_SYNC_INTERVAL_MS"`
+	RateRulesFile              string `mapstructure:"RATE_RULES_FILE"`
+	RateQuotaByToken           int    `mapstructure:"RATE_QUOTA_BY_TOKEN"`
+	RateQuotaCalendar          string `mapstructure:"RATE_QUOTA_CALENDAR"`
+	RateQuotaTimezone          string `mapstructure:"RATE_QUOTA_TIMEZONE"`
+}
+
+// defaults are the values of the fields which are set neither in the .env file, nor in the environment,
+// nor by a flag.
+var defaults = map[string]interface{}{
+	"APP_PORT":                   8080,
+	"REDIS_HOST":                 "localhost",
+	"REDIS_PORT":                 6379,
+	"REDIS_MODE":                 "standalone",
+	"RATE_MAX_REQUESTS_BY_IP":    10,
+	"RATE_MAX_REQUESTS_BY_TOKEN": 100,
+	"RATE_PERIOD_WINDOW_SECONDS": 60,
+	"RATE_HEADER_STYLE":          "legacy",
+	"TRUSTED_HEADER":             limiter.DefaultTrustedHeader,
+	"IPV4_PREFIX":                32,
+	"IPV6_PREFIX":                64,
+	"RATE_FAILURE_POLICY":        "closed",
+	"RATE_QUOTA_CALENDAR":        string(limiter.CalendarMonth),
 }
 
-func Load(path string) (*Config, error) {
+// Load reads the configuration from the .env file in the directory at path, if any, overridden by the
+// environment variables, themselves overridden by the command-line arguments. Each field has a flag
+// named after its variable, e.g. --rate-by-ip for RATE_BY_IP. Without .env file, or with an empty path,
+// the configuration only comes from the environment and the arguments. The configuration is validated,
+// and a *ValidationError lists the invalid values.
+func Load(path string, args ...string) (*Config, error) {
 	var c *Config
 
-	viper.AddConfigPath(path)
-	viper.SetConfigName(".env")
-	viper.SetConfigType("env")
-	//viper.SetConfigFile(".env")
-	viper.AutomaticEnv()
+	// A new instance reads the file again on each call, to reload the configuration.
+	v := viper.New()
+	v.SetConfigName(".env")
+	v.SetConfigType("env")
 
-	if err := viper.ReadInConfig(); err != nil {
-		panic(err)
+	flags := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		key := field.Tag.Get("mapstructure")
+		if value, ok := defaults[key]; ok {
+			v.SetDefault(key, value)
+		}
+
+		// Unlike AutomaticEnv, binding the variables also reads the ones missing from the .env file.
+		if err := v.BindEnv(key); err != nil {
+			return nil, err
+		}
+
+		// Typed flags parse their values, and boolean ones may be given without value, e.g. --redis-tls.
+		name := strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+		usage := fmt.Sprintf("overrides %s", key)
+		switch field.Type.Kind() {
+		case reflect.Bool:
+			flags.Bool(name, false, usage)
+		case reflect.Int:
+			flags.Int(name, 0, usage)
+		default:
+			flags.String(name, "", usage)
+		}
+		if err := v.BindPFlag(key, flags.Lookup(name)); err != nil {
+			return nil, err
+		}
 	}
 
-	if err := viper.Unmarshal(&c); err != nil {
-		panic(err)
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		v.AddConfigPath(path)
+		err := v.ReadInConfig()
+		if _, ok := err.(viper.ConfigFileNotFoundError); err != nil && !ok {
+			return nil, err
+		}
+	}
+
+	if err := v.Unmarshal(&c); err != nil {
+		return nil, err
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
 	}
 
 	return c, nil
 }
+
+// GetRateByIP returns the rate of RATE_BY_IP, or RATE_MAX_REQUESTS_BY_IP requests per
+// RATE_PERIOD_WINDOW_SECONDS if it is not set.
+func (c *Config) GetRateByIP() (limiter.Rate, error) {
+	return getRate(c.RateByIP, c.RateMaxRequestsByIP, c.RatePeriodWindowSeconds)
+}
+
+// GetRateByToken returns the rate of RATE_BY_TOKEN, or RATE_MAX_REQUESTS_BY_TOKEN requests per
+// RATE_PERIOD_WINDOW_SECONDS if it is not set.
+func (c *Config) GetRateByToken() (limiter.Rate, error) {
+	return getRate(c.RateByToken, c.RateMaxRequestsByToken, c.RatePeriodWindowSeconds)
+}
+
+// GetQuotaRate returns the quota of RATE_QUOTA_BY_TOKEN requests per RATE_QUOTA_CALENDAR, aligned to
+// the calendar of RATE_QUOTA_TIMEZONE.
+func (c *Config) GetQuotaRate() (limiter.Rate, error) {
+	calendar := limiter.Calendar(c.RateQuotaCalendar)
+	if calendar == "" {
+		calendar = limiter.CalendarMonth
+	}
+
+	location, err := time.LoadLocation(c.RateQuotaTimezone)
+	if err != nil {
+		return limiter.Rate{}, fmt.Errorf("failed to load quota timezone: %w", err)
+	}
+
+	return limiter.NewCalendarRate(int64(c.RateQuotaByToken), calendar, location), nil
+}
+
+func getRate(value string, limit int, period int) (limiter.Rate, error) {
+	if value == "" {
+		return limiter.NewRate(int64(limit), period), nil
+	}
+	return limiter.ParseRate(value)
+}
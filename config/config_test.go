@@ -0,0 +1,168 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
+)
+
+func TestLoad(t *testing.T) {
+	is := require.New(t)
+
+	dir := t.TempDir()
+	is.NoError(os.WriteFile(filepath.Join(dir, ".env"), []byte(
+		"APP_PORT=9090\nREDIS_HOST=redis\nRATE_MAX_REQUESTS_BY_IP=20\nRATE_BY_TOKEN=100/m\n"), 0o600))
+
+	// The environment overrides the file, and the flags override the environment.
+	t.Setenv("RATE_MAX_REQUESTS_BY_IP", "30")
+	t.Setenv("REDIS_HOST", "redis.internal")
+	t.Setenv("REDIS_TLS", "true")
+
+	cfg, err := config.Load(dir, "--redis-host=localhost", "--ipv4-prefix", "24")
+	is.NoError(err)
+	is.Equal(9090, cfg.AppPort)
+	is.Equal("localhost", cfg.RedisHost)
+	is.True(cfg.RedisTLS)
+	is.Equal(30, cfg.RateMaxRequestsByIP)
+	is.Equal(24, cfg.IPv4Prefix)
+
+	// Unset values keep their default.
+	is.Equal(6379, cfg.RedisPort)
+	is.Equal(60, cfg.RatePeriodWindowSeconds)
+
+	rate, err := cfg.GetRateByIP()
+	is.NoError(err)
+	is.Equal(limiter.Rate{Limit: 30, Period: time.Minute}, rate)
+
+	rate, err = cfg.GetRateByToken()
+	is.NoError(err)
+	is.Equal(limiter.Rate{Limit: 100, Period: time.Minute}, rate)
+
+	cfg, err = config.Load(dir, "--redis-tls=false")
+	is.NoError(err)
+	is.False(cfg.RedisTLS)
+
+	// Boolean flags may be given without value.
+	t.Setenv("REDIS_TLS", "")
+	cfg, err = config.Load(dir, "--redis-tls", "--redis-tls-insecure-skip-verify")
+	is.NoError(err)
+	is.True(cfg.RedisTLS)
+	is.True(cfg.RedisTLSInsecureSkipVerify)
+	is.Equal(30, cfg.RateMaxRequestsByIP)
+
+	_, err = config.Load(dir, "--unknown")
+	is.Error(err)
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	is := require.New(t)
+
+	t.Setenv("APP_PORT", "8081")
+	t.Setenv("RATE_BY_IP", "10/s")
+
+	for _, path := range []string{t.TempDir(), ""} {
+		cfg, err := config.Load(path)
+		is.NoError(err)
+		is.Equal(8081, cfg.AppPort)
+		is.Equal("10/s", cfg.RateByIP)
+		is.Equal(100, cfg.RateMaxRequestsByToken)
+	}
+}
+
+func TestLoadWithInvalidValues(t *testing.T) {
+	is := require.New(t)
+
+	t.Setenv("RATE_PERIOD_WINDOW_SECONDS", "0")
+
+	_, err := config.Load("", "--app-port=70000", "--rate-max-requests-by-ip=-1", "--rate-by-token=10",
+		"--ipv6-prefix=129", "--rate-failure-policy=retry")
+
+	var validationErr *config.ValidationError
+	is.True(errors.As(err, &validationErr))
+
+	fields := []string{}
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	is.Equal([]string{
+		"APP_PORT",
+		"RATE_MAX_REQUESTS_BY_IP",
+		"RATE_BY_TOKEN",
+		"RATE_PERIOD_WINDOW_SECONDS",
+		"RATE_FAILURE_POLICY",
+		"IPV6_PREFIX",
+	}, fields)
+	is.Contains(err.Error(), "APP_PORT=70000: should be a port between 1 and 65535")
+
+	// Values which are not numbers are not validation errors.
+	_, err = config.Load("", "--app-port=http")
+	is.Error(err)
+	is.False(errors.As(err, &validationErr))
+}
+
+func TestConfigValidate(t *testing.T) {
+	is := require.New(t)
+
+	cfg := config.Config{
+		AppPort:                 8080,
+		RedisPort:               6379,
+		RateMaxRequestsByIP:     10,
+		RateMaxRequestsByToken:  100,
+		RatePeriodWindowSeconds: 60,
+		IPv4Prefix:              32,
+		IPv6Prefix:              64,
+	}
+	is.NoError(cfg.Validate())
+
+	invalid := cfg
+	invalid.RedisMode = "cluster"
+	invalid.TokenRegistry = "file"
+	invalid.RateQuotaByToken = 1000
+	invalid.RateQuotaCalendar = "week"
+	invalid.RateQuotaTimezone = "Mars/Olympus"
+	invalid.TrustedProxies = "10.0.0.0/33"
+	invalid.IPv4Prefix = 0
+	err := invalid.Validate()
+	is.ErrorContains(err, "REDIS_ADDRS=: should be set in cluster mode")
+	is.ErrorContains(err, `TOKEN_REGISTRY_FILE=: should be set when TOKEN_REGISTRY="file"`)
+	is.ErrorContains(err, "TRUSTED_PROXIES=10.0.0.0/33")
+	is.ErrorContains(err, `RATE_QUOTA_CALENDAR=week: should be "hour", "day" or "month"`)
+	is.ErrorContains(err, "RATE_QUOTA_TIMEZONE=Mars/Olympus")
+	is.ErrorContains(err, "IPV4_PREFIX=0: should be between 1 and 32")
+
+	// The Redis port is not used with a URL.
+	withURL := cfg
+	withURL.RedisPort = 0
+	withURL.RedisURL = "redis://localhost:6379/0"
+	is.NoError(withURL.Validate())
+}
+
+func TestConfigValidateAdmin(t *testing.T) {
+	is := require.New(t)
+
+	cfg := config.Config{
+		AppPort:                 8080,
+		AdminPort:               9090,
+		AdminToken:              "secret",
+		RedisPort:               6379,
+		RateMaxRequestsByIP:     10,
+		RateMaxRequestsByToken:  100,
+		RatePeriodWindowSeconds: 60,
+		IPv4Prefix:              32,
+		IPv6Prefix:              64,
+	}
+	is.NoError(cfg.Validate())
+
+	cfg.AdminPort = 8080
+	cfg.AdminToken = ""
+	err := cfg.Validate()
+	is.ErrorContains(err, "ADMIN_PORT=8080: should differ from APP_PORT")
+	is.ErrorContains(err, "ADMIN_TOKEN=: should be set when ADMIN_PORT is set")
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// Key strategies of the rules.
+const (
+	KeyIP        = "ip"
+	KeyToken     = "token"
+	KeyTokenOrIP = "token_or_ip"
+	KeyGlobal    = "global"
+	// KeyHeaderPrefix is followed by the name of the header whose value is the key, e.g. "header:X-Tenant".
+	KeyHeaderPrefix = "header:"
+)
+
+// RulesFile is the content of RATE_RULES_FILE, in YAML or, with a .json extension, JSON:
+//
+//	rules:
+//	  - name: login
+//	    path: /api/login
+//	    methods: [POST]
+//	    key: ip
+//	    rate: 5/m
+//	  - name: api
+//	    path: /api/**
+//	    host: api.example.com
+//	    headers: {X-Tenant: "*"}
+//	    key: header:X-Tenant
+//	    rate: 100/s
+//	    rates: [100000/d]
+//
+// The first rule matching a request and returning a key applies.
+type RulesFile struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule describes the requests a rule applies to, its key strategy and its rates. The rate, and the
+// additional rates if any, are written in the format of limiter.ParseRate. Algorithm, burst and block
+// only apply to the rate.
+type Rule struct {
+	Name      string            `json:"name" yaml:"name"`
+	Path      string            `json:"path" yaml:"path"`
+	Methods   []string          `json:"methods" yaml:"methods"`
+	Host      string            `json:"host" yaml:"host"`
+	Headers   map[string]string `json:"headers" yaml:"headers"`
+	Key       string            `json:"key" yaml:"key"`
+	Rate      string            `json:"rate" yaml:"rate"`
+	Rates     []string          `json:"rates" yaml:"rates"`
+	Algorithm string            `json:"algorithm" yaml:"algorithm"`
+	Burst     int64             `json:"burst" yaml:"burst"`
+	Block     string            `json:"block" yaml:"block"`
+}
+
+// LoadRules reads and validates the rules file at given path.
+func LoadRules(path string) (*RulesFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file %q: %w", path, err)
+	}
+
+	var file RulesFile
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.Unmarshal(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %q: %w", path, err)
+	}
+
+	err = file.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %q: %w", path, err)
+	}
+
+	return &file, nil
+}
+
+// defaultRuleNames are the names of the default rules by token and by IP. The keys of a rule being
+// namespaced by its name, a rule of the file named alike, or containing the separator of the
+// namespace, would share the counters of another rule.
+var defaultRuleNames = map[string]bool{"token": true, "ip": true}
+
+// Validate checks that every rule has a unique name, a known key strategy and valid rates.
+func (file *RulesFile) Validate() error {
+	names := make(map[string]bool, len(file.Rules))
+	for i, rule := range file.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("several rules are named %q", rule.Name)
+		}
+		if defaultRuleNames[rule.Name] || strings.Contains(rule.Name, ":") {
+			return fmt.Errorf("rule %q has a reserved name", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if !isKnownKey(rule.Key) {
+			return fmt.Errorf("rule %q has an unknown key %q", rule.Name, rule.Key)
+		}
+
+		_, _, err := rule.GetRates()
+		if err != nil {
+			return fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetRates returns the rate of the rule and its additional rates.
+func (rule Rule) GetRates() (limiter.Rate, []limiter.Rate, error) {
+	rate, err := limiter.ParseRate(rule.Rate)
+	if err != nil {
+		return limiter.Rate{}, nil, err
+	}
+
+	rate.Algorithm = limiter.Algorithm(rule.Algorithm)
+	if !rate.Algorithm.IsValid() {
+		return limiter.Rate{}, nil, fmt.Errorf("unknown algorithm %q", rule.Algorithm)
+	}
+
+	if rule.Burst < 0 {
+		return limiter.Rate{}, nil, fmt.Errorf("burst should not be negative")
+	}
+	rate.Burst = rule.Burst
+
+	if rule.Block != "" {
+		rate.Block, err = time.ParseDuration(rule.Block)
+		if err != nil || rate.Block < 0 {
+			return limiter.Rate{}, nil, fmt.Errorf("invalid block duration %q", rule.Block)
+		}
+	}
+
+	periods := map[time.Duration]bool{rate.Period: true}
+	rates := make([]limiter.Rate, 0, len(rule.Rates))
+	for _, value := range rule.Rates {
+		extra, err := limiter.ParseRate(value)
+		if err != nil {
+			return limiter.Rate{}, nil, err
+		}
+		if periods[extra.Period] {
+			return limiter.Rate{}, nil, fmt.Errorf("several rates have the period %s", extra.Period)
+		}
+		periods[extra.Period] = true
+		rates = append(rates, extra)
+	}
+
+	// Additional rates are checked together with the rate, which is only supported by fixed windows.
+	if len(rates) > 0 && ((rate.Algorithm != "" && rate.Algorithm != limiter.FixedWindow) || rate.Block > 0) {
+		return limiter.Rate{}, nil, fmt.Errorf("additional rates require a fixed window rate without block")
+	}
+
+	return rate, rates, nil
+}
+
+func isKnownKey(key string) bool {
+	switch key {
+	case KeyIP, KeyToken, KeyTokenOrIP, KeyGlobal:
+		return true
+	}
+	return strings.HasPrefix(key, KeyHeaderPrefix) && len(key) > len(KeyHeaderPrefix)
+}
@@ -0,0 +1,90 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
+)
+
+func TestLoadRules(t *testing.T) {
+	is := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	is.NoError(os.WriteFile(path, []byte(`
+rules:
+  - name: login
+    path: /api/login
+    methods: [POST]
+    key: ip
+    rate: 5/m
+    algorithm: gcra
+    burst: 2
+  - name: tenant
+    path: /api/**
+    headers: {X-Tenant: "*"}
+    key: header:X-Tenant
+    rate: 100/s
+    rates: [100000/d]
+`), 0o600))
+
+	file, err := config.LoadRules(path)
+	is.NoError(err)
+	is.Len(file.Rules, 2)
+	is.Equal([]string{"POST"}, file.Rules[0].Methods)
+	is.Equal(map[string]string{"X-Tenant": "*"}, file.Rules[1].Headers)
+
+	rate, rates, err := file.Rules[0].GetRates()
+	is.NoError(err)
+	is.Equal(limiter.Rate{Limit: 5, Period: time.Minute, Burst: 2, Algorithm: limiter.GCRA}, rate)
+	is.Empty(rates)
+
+	rate, rates, err = file.Rules[1].GetRates()
+	is.NoError(err)
+	is.Equal(limiter.Rate{Limit: 100, Period: time.Second}, rate)
+	is.Equal([]limiter.Rate{{Limit: 100000, Period: 24 * time.Hour}}, rates)
+
+	path = filepath.Join(t.TempDir(), "rules.json")
+	is.NoError(os.WriteFile(path, []byte(`{"rules": [{"name": "all", "key": "global", "rate": "1000/s"}]}`), 0o600))
+
+	file, err = config.LoadRules(path)
+	is.NoError(err)
+	is.Equal("global", file.Rules[0].Key)
+
+	_, err = config.LoadRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	is.Error(err)
+}
+
+func TestRulesFileValidate(t *testing.T) {
+	is := require.New(t)
+
+	invalid := map[string]config.Rule{
+		"no name":           {Key: "ip", Rate: "10/s"},
+		"token name":        {Name: "token", Key: "ip", Rate: "10/s"},
+		"ip name":           {Name: "ip", Key: "ip", Rate: "10/s"},
+		"separator in name": {Name: "a:b", Key: "ip", Rate: "10/s"},
+		"unknown key":       {Name: "a", Key: "cookie", Rate: "10/s"},
+		"empty header":      {Name: "a", Key: "header:", Rate: "10/s"},
+		"invalid rate":      {Name: "a", Key: "ip", Rate: "10"},
+		"unknown algorithm": {Name: "a", Key: "ip", Rate: "10/s", Algorithm: "leaky"},
+		"invalid block":     {Name: "a", Key: "ip", Rate: "10/s", Block: "soon"},
+		"same period":       {Name: "a", Key: "ip", Rate: "10/s", Rates: []string{"20/1s"}},
+		"sliding rates":     {Name: "a", Key: "ip", Rate: "10/s", Rates: []string{"20/m"}, Algorithm: "gcra"},
+	}
+	for name, rule := range invalid {
+		file := config.RulesFile{Rules: []config.Rule{rule}}
+		is.Error(file.Validate(), name)
+	}
+
+	rule := config.Rule{Name: "a", Key: "token", Rate: "10/s", Block: "1m"}
+	file := config.RulesFile{Rules: []config.Rule{rule, rule}}
+	is.Error(file.Validate())
+
+	file = config.RulesFile{Rules: []config.Rule{rule}}
+	is.NoError(file.Validate())
+}
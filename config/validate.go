@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// FieldError is an invalid value of a configuration variable.
+type FieldError struct {
+	Field  string
+	Value  interface{}
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s=%v: %s", e.Field, e.Value, e.Reason)
+}
+
+// ValidationError lists the invalid values of a configuration.
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) add(field string, value interface{}, reason string) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Value: value, Reason: reason})
+}
+
+// Validate checks the values of the configuration, and returns a *ValidationError listing the invalid
+// ones, if any.
+func (c *Config) Validate() error {
+	e := &ValidationError{}
+
+	checkPort(e, "APP_PORT", c.AppPort)
+	if c.AdminPort != 0 {
+		checkPort(e, "ADMIN_PORT", c.AdminPort)
+		if c.AdminPort == c.AppPort {
+			e.add("ADMIN_PORT", c.AdminPort, "should differ from APP_PORT")
+		}
+		if c.AdminToken == "" {
+			e.add("ADMIN_TOKEN", "", "should be set when ADMIN_PORT is set")
+		}
+	}
+	if c.RedisURL == "" && (c.RedisMode == "" || c.RedisMode == "standalone") {
+		checkPort(e, "REDIS_PORT", c.RedisPort)
+	}
+
+	switch c.RedisMode {
+	case "", "standalone":
+	case "sentinel", "cluster":
+		if c.RedisAddrs == "" {
+			e.add("REDIS_ADDRS", c.RedisAddrs, fmt.Sprintf("should be set in %s mode", c.RedisMode))
+		}
+	default:
+		e.add("REDIS_MODE", c.RedisMode, `should be "standalone", "sentinel" or "cluster"`)
+	}
+
+	if c.RateByIP == "" {
+		checkPositive(e, "RATE_MAX_REQUESTS_BY_IP", c.RateMaxRequestsByIP)
+	} else if _, err := limiter.ParseRate(c.RateByIP); err != nil {
+		e.add("RATE_BY_IP", c.RateByIP, err.Error())
+	}
+
+	if c.RateByToken == "" {
+		checkPositive(e, "RATE_MAX_REQUESTS_BY_TOKEN", c.RateMaxRequestsByToken)
+	} else if _, err := limiter.ParseRate(c.RateByToken); err != nil {
+		e.add("RATE_BY_TOKEN", c.RateByToken, err.Error())
+	}
+
+	if c.RateByIP == "" || c.RateByToken == "" {
+		checkPositive(e, "RATE_PERIOD_WINDOW_SECONDS", c.RatePeriodWindowSeconds)
+	}
+
+	switch c.TokenRegistry {
+	case "", "redis":
+	case "file":
+		if c.TokenRegistryFile == "" {
+			e.add("TOKEN_REGISTRY_FILE", c.TokenRegistryFile, `should be set when TOKEN_REGISTRY="file"`)
+		}
+	default:
+		e.add("TOKEN_REGISTRY", c.TokenRegistry, `should be "", "file" or "redis"`)
+	}
+
+	switch c.RateHeaderStyle {
+	case "", "legacy", "ietf", "both":
+	default:
+		e.add("RATE_HEADER_STYLE", c.RateHeaderStyle, `should be "legacy", "ietf" or "both"`)
+	}
+
+	switch c.RateFailurePolicy {
+	case "", "closed", "open", "fallback":
+	default:
+		e.add("RATE_FAILURE_POLICY", c.RateFailurePolicy, `should be "closed", "open" or "fallback"`)
+	}
+
+	if c.TrustedProxies != "" {
+		if _, err := limiter.ParseNetworks(strings.Split(c.TrustedProxies, ",")); err != nil {
+			e.add("TRUSTED_PROXIES", c.TrustedProxies, err.Error())
+		}
+	}
+
+	checkRange(e, "IPV4_PREFIX", c.IPv4Prefix, 1, 32)
+	checkRange(e, "IPV6_PREFIX", c.IPv6Prefix, 1, 128)
+	checkNotNegative(e, "REDIS_POOL_SIZE", c.RedisPoolSize)
+	checkNotNegative(e, "REDIS_MIN_IDLE_CONNS", c.RedisMinIdleConns)
+	checkNotNegative(e, "RATE_STORE_TIMEOUT_MS", c.RateStoreTimeoutMs)
+	checkNotNegative(e, "RATE_SYNC_INTERVAL_MS", c.RateSyncIntervalMs)
+	checkNotNegative(e, "RATE_QUOTA_BY_TOKEN", c.RateQuotaByToken)
+
+	if c.RateQuotaByToken > 0 {
+		switch limiter.Calendar(c.RateQuotaCalendar) {
+		case "", limiter.CalendarHour, limiter.CalendarDay, limiter.CalendarMonth:
+		default:
+			e.add("RATE_QUOTA_CALENDAR", c.RateQuotaCalendar, `should be "hour", "day" or "month"`)
+		}
+		if _, err := time.LoadLocation(c.RateQuotaTimezone); err != nil {
+			e.add("RATE_QUOTA_TIMEZONE", c.RateQuotaTimezone, err.Error())
+		}
+	}
+
+	if len(e.Errors) > 0 {
+		return e
+	}
+	return nil
+}
+
+func checkPort(e *ValidationError, field string, value int) {
+	if value < 1 || value > 65535 {
+		e.add(field, value, "should be a port between 1 and 65535")
+	}
+}
+
+func checkPositive(e *ValidationError, field string, value int) {
+	if value <= 0 {
+		e.add(field, value, "should be positive")
+	}
+}
+
+func checkNotNegative(e *ValidationError, field string, value int) {
+	if value < 0 {
+		e.add(field, value, "should not be negative")
+	}
+}
+
+func checkRange(e *ValidationError, field string, value int, min int, max int) {
+	if value < min || value > max {
+		e.add(field, value, fmt.Sprintf("should be between %d and %d", min, max))
+	}
+}
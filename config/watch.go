@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDelay is how long Watch waits for writes to settle before reloading.
+const DefaultWatchDelay = 100 * time.Millisecond
+
+// Watch calls reload whenever one of the files is written, replaced or removed, or the process receives
+// SIGHUP, until the context is done. The directories of the files are watched, so files replaced by
+// editors are still followed. Successive changes within DefaultWatchDelay trigger
+// a single reload.
+func Watch(ctx context.Context, paths []string, reload func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch config files: %w", err)
+	}
+
+	files := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		path, err = filepath.Abs(path)
+		if err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("failed to watch config file %q: %w", path, err)
+		}
+
+		files[path] = true
+		err = watcher.Add(filepath.Dir(path))
+		if err != nil {
+			_ = watcher.Close()
+			return fmt.Errorf("failed to watch config file %q: %w", path, err)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(signals)
+
+		timer := time.NewTimer(DefaultWatchDelay)
+		timer.Stop()
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if files[event.Name] && !event.Has(fsnotify.Chmod) {
+					timer.Reset(DefaultWatchDelay)
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			case <-signals:
+				reload()
+			case <-timer.C:
+				reload()
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,58 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/config"
+)
+
+func TestWatch(t *testing.T) {
+	is := require.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rules.yaml")
+	is.NoError(os.WriteFile(path, []byte("rules: []"), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloads := make(chan struct{}, 10)
+	err := config.Watch(ctx, []string{path}, func() {
+		reloads <- struct{}{}
+	})
+	is.NoError(err)
+
+	// Other files of the directory are ignored.
+	is.NoError(os.WriteFile(filepath.Join(dir, "other.yaml"), []byte("rules: []"), 0o600))
+	is.Never(func() bool { return len(reloads) > 0 }, 3*config.DefaultWatchDelay, 10*time.Millisecond)
+
+	// Successive writes trigger a single reload.
+	is.NoError(os.WriteFile(path, []byte("rules: [{name: a}]"), 0o600))
+	is.NoError(os.WriteFile(path, []byte("rules: [{name: b}]"), 0o600))
+	is.Eventually(func() bool { return len(reloads) > 0 }, time.Second, 10*time.Millisecond)
+	<-reloads
+	is.Never(func() bool { return len(reloads) > 0 }, 3*config.DefaultWatchDelay, 10*time.Millisecond)
+
+	// Files replaced by a rename are still followed.
+	replacement := filepath.Join(dir, "rules.yaml.tmp")
+	is.NoError(os.WriteFile(replacement, []byte("rules: [{name: c}]"), 0o600))
+	is.NoError(os.Rename(replacement, path))
+	is.Eventually(func() bool { return len(reloads) > 0 }, time.Second, 10*time.Millisecond)
+	<-reloads
+
+	is.NoError(syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	is.Eventually(func() bool { return len(reloads) > 0 }, time.Second, 10*time.Millisecond)
+	<-reloads
+
+	cancel()
+	time.Sleep(10 * time.Millisecond)
+	is.NoError(os.WriteFile(path, []byte("rules: []"), 0o600))
+	is.Never(func() bool { return len(reloads) > 0 }, 3*config.DefaultWatchDelay, 10*time.Millisecond)
+}
@@ -0,0 +1,269 @@
+package stdlib
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// AdminHandler serves an HTTP API to inspect, reset and credit the keys of a middleware:
+//
+//	GET    /keys              lists the keys of the store, by batches of ?count= keys from ?cursor=
+//	GET    /keys/top          returns the ?n= keys with the highest count, 10 by default
+//	GET    /keys/blocked      returns the keys which are currently blocked
+//	GET    /keys/{key}        returns the state of the key, without consuming it
+//	DELETE /keys/{key}        resets the key, unblocking it
+//	POST   /keys/{key}/grant  gives back up to {"count": n} consumed requests to the key
+//
+// Keys are the ones stored by the middleware. With rules, they are prefixed by the name of their rule,
+// e.g. "ip:192.168.0.1", whose rates apply. Listing keys requires a limiter.ScanStore. Every request
+// must carry the token as a bearer token.
+type AdminHandler struct {
+	Middleware *Middleware
+	Token      string
+	mux        *http.ServeMux
+}
+
+// AdminContext is the state of a key, as returned by the admin API.
+type AdminContext struct {
+	Key          string `json:"key"`
+	Limit        int64  `json:"limit"`
+	Remaining    int64  `json:"remaining"`
+	Reset        int64  `json:"reset"`
+	Reached      bool   `json:"reached"`
+	BlockedUntil int64  `json:"blocked_until,omitempty"`
+}
+
+// AdminKeyState is the usage of a key, as listed by the admin API.
+type AdminKeyState struct {
+	Key          string `json:"key"`
+	Count        int64  `json:"count"`
+	Reset        int64  `json:"reset"`
+	BlockedUntil int64  `json:"blocked_until,omitempty"`
+}
+
+type adminKeys struct {
+	Keys   []AdminKeyState `json:"keys"`
+	Cursor *uint64         `json:"cursor,omitempty"`
+}
+
+type grantRequest struct {
+	Count int64 `json:"count"`
+}
+
+type adminError struct {
+	Error string `json:"error"`
+}
+
+func NewAdminHandler(middleware *Middleware, token string) *AdminHandler {
+	handler := &AdminHandler{
+		Middleware: middleware,
+		Token:      token,
+		mux:        http.NewServeMux(),
+	}
+
+	handler.mux.HandleFunc("GET /keys", handler.scan)
+	handler.mux.HandleFunc("GET /keys/top", handler.top)
+	handler.mux.HandleFunc("GET /keys/blocked", handler.blocked)
+	handler.mux.HandleFunc("GET /keys/{key}", handler.peek)
+	handler.mux.HandleFunc("DELETE /keys/{key}", handler.reset)
+	handler.mux.HandleFunc("POST /keys/{key}/grant", handler.grant)
+
+	return handler
+}
+
+func (handler *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || handler.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(handler.Token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, adminError{Error: "invalid or missing bearer token"})
+		return
+	}
+
+	handler.mux.ServeHTTP(w, r)
+}
+
+func (handler *AdminHandler) scan(w http.ResponseWriter, r *http.Request) {
+	store, ok := handler.getScanStore(w)
+	if !ok {
+		return
+	}
+
+	cursor, err1 := getQueryInt(r, "cursor", 0)
+	count, err2 := getQueryInt(r, "count", limiter.DefaultScanCount)
+	if err1 != nil || err2 != nil || cursor < 0 || count <= 0 {
+		writeJSON(w, http.StatusBadRequest, adminError{Error: "cursor and count should be positive integers"})
+		return
+	}
+
+	states, next, err := store.Scan(r.Context(), uint64(cursor), int64(count))
+	writeKeyStates(w, states, &next, err)
+}
+
+func (handler *AdminHandler) top(w http.ResponseWriter, r *http.Request) {
+	store, ok := handler.getScanStore(w)
+	if !ok {
+		return
+	}
+
+	n, err := getQueryInt(r, "n", 10)
+	if err != nil || n <= 0 {
+		writeJSON(w, http.StatusBadRequest, adminError{Error: "n should be a positive integer"})
+		return
+	}
+
+	states, err := limiter.TopKeys(r.Context(), store, n)
+	writeKeyStates(w, states, nil, err)
+}
+
+func (handler *AdminHandler) blocked(w http.ResponseWriter, r *http.Request) {
+	store, ok := handler.getScanStore(w)
+	if !ok {
+		return
+	}
+
+	states, err := limiter.BlockedKeys(r.Context(), store)
+	writeKeyStates(w, states, nil, err)
+}
+
+func (handler *AdminHandler) getScanStore(w http.ResponseWriter) (limiter.ScanStore, bool) {
+	store, ok := handler.Middleware.GetLimiter().Store.(limiter.ScanStore)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, adminError{Error: limiter.ErrScanUnsupported.Error()})
+	}
+	return store, ok
+}
+
+func (handler *AdminHandler) peek(w http.ResponseWriter, r *http.Request) {
+	key, limiter, ok := handler.getLimiter(w, r)
+	if !ok {
+		return
+	}
+
+	lctx, err := limiter.Peek(r.Context(), key)
+	writeContext(w, key, lctx, err)
+}
+
+func (handler *AdminHandler) reset(w http.ResponseWriter, r *http.Request) {
+	key, limiter, ok := handler.getLimiter(w, r)
+	if !ok {
+		return
+	}
+
+	lctx, err := limiter.Reset(r.Context(), key)
+	writeContext(w, key, lctx, err)
+}
+
+// grant credits requests to the key by incrementing it by a negative count. The credit is capped by the
+// requests the key consumed, so the key never holds more than its limit.
+func (handler *AdminHandler) grant(w http.ResponseWriter, r *http.Request) {
+	key, limiter, ok := handler.getLimiter(w, r)
+	if !ok {
+		return
+	}
+
+	var request grantRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil || request.Count <= 0 {
+		writeJSON(w, http.StatusBadRequest, adminError{Error: `the body should be {"count": n}, with n positive`})
+		return
+	}
+
+	// The stores do not bring the counters below zero.
+	lctx, err := limiter.Inc(r.Context(), key, -request.Count)
+	writeContext(w, key, lctx, err)
+}
+
+// getLimiter returns the key of the request and the limiter of its rates, or writes the error.
+func (handler *AdminHandler) getLimiter(w http.ResponseWriter, r *http.Request) (string, *limiter.Limiter, bool) {
+	key := r.PathValue("key")
+	limiter, ok, err := handler.Middleware.getLimiter(r.Context(), key)
+	switch {
+	case err != nil:
+		writeJSON(w, http.StatusServiceUnavailable, adminError{Error: err.Error()})
+	case !ok:
+		writeJSON(w, http.StatusNotFound, adminError{Error: "no rule limits the key " + key})
+	}
+	return key, limiter, err == nil && ok
+}
+
+// getLimiter returns a limiter enforcing the rates which apply to a stored key: the rates of the rule
+// named by its prefix or, without rules, the rates of the limiter of the middleware. The rate registered
+// for the key without its prefix applies instead, if the key is a token of the token registry.
+func (middleware *Middleware) getLimiter(ctx context.Context, key string) (*limiter.Limiter, bool, error) {
+	rules, limiter := middleware.getRulesAndLimiter()
+	if len(rules) == 0 {
+		rate, err := middleware.getTokenRate(ctx, strings.TrimSpace(key), limiter.Rate, limiter.Rates)
+		rule := NewRule(DefaultPolicyName, middleware.KeyGetter, rate, limiter.Rates...)
+		return rule.newLimiter(limiter.Store), true, err
+	}
+
+	name, token, _ := strings.Cut(key, ":")
+	for _, rule := range rules {
+		if rule.Name == name {
+			rate, err := middleware.getTokenRate(ctx, strings.TrimSpace(token),
+				inheritRate(rule.Rate, limiter.Rate), rule.Rates)
+			rule.Rate = rate
+			return rule.newLimiter(limiter.Store), true, err
+		}
+	}
+
+	return nil, false, nil
+}
+
+func writeContext(w http.ResponseWriter, key string, lctx limiter.Context, err error) {
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, adminError{Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, AdminContext{
+		Key:          key,
+		Limit:        lctx.Limit,
+		Remaining:    lctx.Remaining,
+		Reset:        lctx.Reset,
+		Reached:      lctx.Reached,
+		BlockedUntil: lctx.BlockedUntil,
+	})
+}
+
+func writeKeyStates(w http.ResponseWriter, states []limiter.KeyState, cursor *uint64, err error) {
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, adminError{Error: err.Error()})
+		return
+	}
+
+	now := time.Now()
+	keys := make([]AdminKeyState, len(states))
+	for i, state := range states {
+		keys[i] = AdminKeyState{
+			Key:   state.Key,
+			Count: state.Count,
+			Reset: now.Add(state.TTL).Unix(),
+		}
+		if state.BlockedFor > 0 {
+			keys[i].BlockedUntil = now.Add(state.BlockedFor).Unix()
+		}
+	}
+
+	writeJSON(w, http.StatusOK, adminKeys{Keys: keys, Cursor: cursor})
+}
+
+func getQueryInt(r *http.Request, name string, value int) (int, error) {
+	if r.URL.Query().Has(name) {
+		return strconv.Atoi(r.URL.Query().Get(name))
+	}
+	return value, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,255 @@
+package stdlib_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+	mregistry "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/memory"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
+)
+
+func TestAdminHandler(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:admin-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+		Block:  1 * time.Minute,
+	}
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithRules(stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), rate)),
+	)
+	limited := middleware.Handler(handler)
+	admin := stdlib.NewAdminHandler(middleware, "secret")
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	serve := func(h http.Handler) int {
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, request)
+		return resp.Code
+	}
+
+	call := func(method string, path string, body string) (int, stdlib.AdminContext) {
+		request, err := http.NewRequest(method, path, strings.NewReader(body))
+		is.NoError(err)
+		request.Header.Set("Authorization", "Bearer secret")
+
+		resp := httptest.NewRecorder()
+		admin.ServeHTTP(resp, request)
+		is.Equal("application/json; charset=utf-8", resp.Header().Get("Content-Type"))
+
+		var lctx stdlib.AdminContext
+		is.NoError(json.Unmarshal(resp.Body.Bytes(), &lctx))
+		return resp.Code, lctx
+	}
+
+	for _, code := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		is.Equal(code, serve(limited))
+	}
+
+	// Peeking does not consume the key.
+	for i := 0; i < 2; i++ {
+		code, lctx := call("GET", "/keys/ip:192.168.0.1", "")
+		is.Equal(http.StatusOK, code)
+		is.Equal("ip:192.168.0.1", lctx.Key)
+		is.Equal(int64(2), lctx.Limit)
+		is.True(lctx.Reached)
+		is.NotZero(lctx.BlockedUntil)
+	}
+
+	code, lctx := call("DELETE", "/keys/ip:192.168.0.1", "")
+	is.Equal(http.StatusOK, code)
+	is.False(lctx.Reached)
+	is.Equal(int64(2), lctx.Remaining)
+	is.Equal(http.StatusOK, serve(limited))
+
+	// The credit is capped by the single request consumed.
+	code, lctx = call("POST", "/keys/ip:192.168.0.1/grant", `{"count": 3}`)
+	is.Equal(http.StatusOK, code)
+	is.Equal(int64(2), lctx.Remaining)
+
+	for _, code := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		is.Equal(code, serve(limited))
+	}
+
+	code, _ = call("POST", "/keys/ip:192.168.0.1/grant", `{"count": 0}`)
+	is.Equal(http.StatusBadRequest, code)
+
+	code, _ = call("GET", "/keys/token:abc", "")
+	is.Equal(http.StatusNotFound, code)
+
+	for _, authorization := range []string{"", "Bearer", "Bearer other", "secret"} {
+		request, err := http.NewRequest("GET", "/keys/ip:192.168.0.1", nil)
+		is.NoError(err)
+		request.Header.Set("Authorization", authorization)
+
+		resp := httptest.NewRecorder()
+		admin.ServeHTTP(resp, request)
+		is.Equal(http.StatusUnauthorized, resp.Code)
+		is.Equal("Bearer", resp.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestAdminHandlerWithTokenRegistry(t *testing.T) {
+	is := require.New(t)
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:admin-registry-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	}
+
+	registry := mregistry.NewRegistry(map[string]limiter.Rate{
+		"abc": {Limit: 5, Period: time.Minute},
+	})
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithRules(stdlib.NewRule("token", stdlib.WithTokenKeyGetter(limiter), rate)),
+		stdlib.WithTokenRegistry(registry),
+	)
+	admin := stdlib.NewAdminHandler(middleware, "secret")
+
+	// The rate registered for the token applies, as it does to the requests carrying it.
+	for key, limit := range map[string]int64{"token:abc": 5, "token:other": 2} {
+		request, err := http.NewRequest("GET", "/keys/"+key, nil)
+		is.NoError(err)
+		request.Header.Set("Authorization", "Bearer secret")
+
+		resp := httptest.NewRecorder()
+		admin.ServeHTTP(resp, request)
+		is.Equal(http.StatusOK, resp.Code)
+
+		var lctx stdlib.AdminContext
+		is.NoError(json.Unmarshal(resp.Body.Bytes(), &lctx))
+		is.Equal(limit, lctx.Limit, key)
+	}
+}
+
+// scanStore lists fixed states on top of a memory store.
+type scanStore struct {
+	limiter.Store
+	states []limiter.KeyState
+}
+
+func (store *scanStore) Scan(ctx context.Context, cursor uint64, count int64) ([]limiter.KeyState, uint64, error) {
+	end := cursor + uint64(count)
+	if end >= uint64(len(store.states)) {
+		return store.states[cursor:], 0, nil
+	}
+	return store.states[cursor:end], end, nil
+}
+
+func TestAdminHandlerKeys(t *testing.T) {
+	is := require.New(t)
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:admin-keys-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{
+		Limit:  10,
+		Period: 1 * time.Minute,
+	}
+
+	call := func(admin *stdlib.AdminHandler, path string) (int, map[string]json.RawMessage) {
+		request, err := http.NewRequest("GET", path, nil)
+		is.NoError(err)
+		request.Header.Set("Authorization", "Bearer secret")
+
+		resp := httptest.NewRecorder()
+		admin.ServeHTTP(resp, request)
+
+		var body map[string]json.RawMessage
+		is.NoError(json.Unmarshal(resp.Body.Bytes(), &body))
+		return resp.Code, body
+	}
+
+	keys := func(body map[string]json.RawMessage) []stdlib.AdminKeyState {
+		var states []stdlib.AdminKeyState
+		is.NoError(json.Unmarshal(body["keys"], &states))
+		return states
+	}
+
+	// The memory store does not list its keys.
+	admin := stdlib.NewAdminHandler(stdlib.NewMiddleware(limiter.NewLimiter(store, rate)), "secret")
+	for _, path := range []string{"/keys", "/keys/top", "/keys/blocked"} {
+		code, _ := call(admin, path)
+		is.Equal(http.StatusNotImplemented, code)
+	}
+
+	scan := &scanStore{
+		Store: store,
+		states: []limiter.KeyState{
+			{Key: "ip:a", Count: 3, TTL: time.Minute},
+			{Key: "ip:b", Count: 10, TTL: time.Minute, BlockedFor: time.Hour},
+			{Key: "ip:c", Count: 5, TTL: time.Minute},
+		},
+	}
+	admin = stdlib.NewAdminHandler(stdlib.NewMiddleware(limiter.NewLimiter(scan, rate)), "secret")
+
+	code, body := call(admin, "/keys?count=2")
+	is.Equal(http.StatusOK, code)
+	is.Len(keys(body), 2)
+	is.Equal("2", string(body["cursor"]))
+
+	code, body = call(admin, "/keys?cursor=2&count=2")
+	is.Equal(http.StatusOK, code)
+	is.Equal("ip:c", keys(body)[0].Key)
+	is.Equal("0", string(body["cursor"]))
+
+	code, body = call(admin, "/keys/top?n=2")
+	is.Equal(http.StatusOK, code)
+	top := keys(body)
+	is.Len(top, 2)
+	is.Equal("ip:b", top[0].Key)
+	is.Equal(int64(10), top[0].Count)
+	is.Equal("ip:c", top[1].Key)
+	is.Zero(top[1].BlockedUntil)
+	is.Nil(body["cursor"])
+
+	code, body = call(admin, "/keys/blocked")
+	is.Equal(http.StatusOK, code)
+	blocked := keys(body)
+	is.Len(blocked, 1)
+	is.Equal("ip:b", blocked[0].Key)
+	is.Greater(blocked[0].BlockedUntil, time.Now().Unix())
+
+	for _, path := range []string{"/keys?count=0", "/keys?cursor=-1", "/keys/top?n=x"} {
+		code, _ = call(admin, path)
+		is.Equal(http.StatusBadRequest, code)
+	}
+}
@@ -0,0 +1,104 @@
+package stdlib
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// ConcurrencyMiddleware caps the number of in-flight requests of each key. The slot of a request is
+// held while the wrapped handler runs, renewing its lease, and released when the handler returns.
+type ConcurrencyMiddleware struct {
+	Limiter        *limiter.ConcurrencyLimiter
+	OnError        ErrorHandler
+	OnLimitReached LimitReachedHandler
+	KeyGetter      KeyGetter
+}
+
+// NewConcurrencyMiddleware returns a middleware keying requests by the IP address of the connection,
+// unless WithKeyGetter is given. Among the options, only WithErrorHandler, WithLimitReachedHandler and
+// WithKeyGetter apply.
+func NewConcurrencyMiddleware(l *limiter.ConcurrencyLimiter, options ...Option) *ConcurrencyMiddleware {
+	middleware := &Middleware{
+		OnError:        WithDefaultErrorHandler,
+		OnLimitReached: WithDefaultLimitReachedHandler,
+		KeyGetter:      withConnectionIPKeyGetter,
+	}
+
+	for _, option := range options {
+		option.apply(middleware)
+	}
+
+	return &ConcurrencyMiddleware{
+		Limiter:        l,
+		OnError:        middleware.OnError,
+		OnLimitReached: middleware.OnLimitReached,
+		KeyGetter:      middleware.KeyGetter,
+	}
+}
+
+func withConnectionIPKeyGetter(r *http.Request) string {
+	return limiter.MaskIP(limiter.GetIP(r), limiter.DefaultIPv4Mask, limiter.DefaultIPv6Mask).String()
+}
+
+func (middleware *ConcurrencyMiddleware) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := middleware.KeyGetter(r)
+		if strings.TrimSpace(key) == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		lease, err := middleware.Limiter.Acquire(r.Context(), key)
+		if err != nil {
+			middleware.OnError(w, r, err)
+			return
+		}
+
+		w.Header().Add("X-Concurrency-Limit", strconv.FormatInt(lease.Limit, 10))
+
+		if !lease.Acquired {
+			middleware.OnLimitReached(w, r)
+			return
+		}
+
+		// The lease is released even if the client went away.
+		ctx := context.WithoutCancel(r.Context())
+		done := make(chan struct{})
+		go middleware.renew(ctx, lease, done)
+
+		defer func() {
+			close(done)
+			_ = middleware.Limiter.Release(ctx, lease)
+		}()
+
+		h.ServeHTTP(w, r)
+	})
+}
+
+// renew renews the lease every half of its TTL, until done is closed.
+func (middleware *ConcurrencyMiddleware) renew(ctx context.Context, lease limiter.Lease, done chan struct{}) {
+	interval := middleware.Limiter.TTL / 2
+	if interval <= 0 {
+		interval = limiter.DefaultLeaseTTL / 2
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			renewed, err := middleware.Limiter.Renew(ctx, lease)
+			if err == nil {
+				lease = renewed
+			}
+		case <-done:
+			return
+		}
+	}
+}
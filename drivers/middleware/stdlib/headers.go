@@ -0,0 +1,84 @@
+package stdlib
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// HeaderStyle defines which rate limit headers are written on responses.
+type HeaderStyle string
+
+const (
+	// HeaderStyleLegacy writes X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset,
+	// with the reset as a Unix timestamp.
+	HeaderStyleLegacy HeaderStyle = "legacy"
+	// HeaderStyleIETF writes the RateLimit-Policy and RateLimit structured headers of the IETF draft,
+	// with the reset in delta-seconds.
+	HeaderStyleIETF HeaderStyle = "ietf"
+	// HeaderStyleBoth writes both the legacy and the IETF headers.
+	HeaderStyleBoth HeaderStyle = "both"
+)
+
+// DefaultPolicyName names the policy of the IETF headers when the request is not matched by a rule.
+const DefaultPolicyName = "default"
+
+func setHeaders(w http.ResponseWriter, style HeaderStyle, rule Rule, context limiter.Context, now time.Time) {
+	if style != HeaderStyleIETF {
+		w.Header().Add("X-RateLimit-Limit", strconv.FormatInt(context.Limit, 10))
+		w.Header().Add("X-RateLimit-Remaining", strconv.FormatInt(context.Remaining, 10))
+		w.Header().Add("X-RateLimit-Reset", strconv.FormatInt(context.Reset, 10))
+	}
+
+	if style == HeaderStyleIETF || style == HeaderStyleBoth {
+		rate := getContextRate(rule, context)
+		w.Header().Add("RateLimit-Policy", fmt.Sprintf("%q;q=%d;w=%d",
+			rule.Name, context.Limit, int64(getPolicyWindow(rate)/time.Second)))
+		w.Header().Add("RateLimit", fmt.Sprintf("%q;r=%d;t=%d",
+			rule.Name, context.Remaining, getResetSeconds(context, now)))
+	}
+}
+
+// getContextRate returns the rate of the rule reported by the context, which is the most restrictive
+// one when the rule has several rates.
+func getContextRate(rule Rule, context limiter.Context) limiter.Rate {
+	if context.RateIndex > 0 && context.RateIndex <= len(rule.Rates) {
+		return rule.Rates[context.RateIndex-1]
+	}
+	return rule.Rate
+}
+
+// getPolicyWindow returns the window of the quota reported for the rate. With GCRA, the quota is the
+// burst, which is refilled in burst emission intervals.
+func getPolicyWindow(rate limiter.Rate) time.Duration {
+	if rate.Algorithm != limiter.GCRA || rate.Burst <= 0 || rate.Limit <= 0 {
+		return rate.Period
+	}
+	return rate.Period / time.Duration(rate.Limit) * time.Duration(rate.Burst)
+}
+
+// setRetryAfter tells a rejected client how many seconds to wait before retrying.
+func setRetryAfter(w http.ResponseWriter, context limiter.Context, now time.Time) {
+	seconds := getResetSeconds(context, now)
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+// getResetSeconds returns the number of seconds until the limit of the context resets.
+func getResetSeconds(context limiter.Context, now time.Time) int64 {
+	reset := context.Reset
+	if context.BlockedUntil > reset {
+		reset = context.BlockedUntil
+	}
+
+	seconds := reset - now.Unix()
+	if seconds < 0 {
+		return 0
+	}
+	return seconds
+}
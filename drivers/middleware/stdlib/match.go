@@ -0,0 +1,85 @@
+package stdlib
+
+import (
+	"net"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// RequestMatcher reports whether a rule applies to a request.
+type RequestMatcher func(r *http.Request) bool
+
+// WithMatchers returns a key getter returning the key of given key getter for the requests matched
+// by every matcher, and an empty key otherwise, so rules only apply to the requests they match.
+func WithMatchers(keyGetter KeyGetter, matchers ...RequestMatcher) KeyGetter {
+	return func(r *http.Request) string {
+		for _, matcher := range matchers {
+			if !matcher(r) {
+				return ""
+			}
+		}
+		return keyGetter(r)
+	}
+}
+
+// MatchPath matches the requests whose path matches the pattern, with the syntax of path.Match.
+// A pattern ending with "/**" also matches every path below it, e.g. "/api/**" matches "/api/a/b".
+func MatchPath(pattern string) RequestMatcher {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return func(r *http.Request) bool {
+			return r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/")
+		}
+	}
+
+	return func(r *http.Request) bool {
+		ok, _ := path.Match(pattern, r.URL.Path)
+		return ok
+	}
+}
+
+// MatchMethods matches the requests with one of given methods.
+func MatchMethods(methods ...string) RequestMatcher {
+	return func(r *http.Request) bool {
+		for _, method := range methods {
+			if strings.EqualFold(method, r.Method) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchHost matches the requests whose host, without port, matches the pattern, with the syntax of
+// path.Match, e.g. "*.example.com". The host is compared case-insensitively.
+func MatchHost(pattern string) RequestMatcher {
+	pattern = strings.ToLower(pattern)
+
+	return func(r *http.Request) bool {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+
+		ok, _ := path.Match(pattern, strings.ToLower(host))
+		return ok
+	}
+}
+
+// MatchHeader matches the requests with given header value, or carrying the header at all if the
+// value is empty or "*".
+func MatchHeader(name string, value string) RequestMatcher {
+	return func(r *http.Request) bool {
+		values := r.Header.Values(name)
+		if value == "" || value == "*" {
+			return len(values) > 0
+		}
+
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+		return false
+	}
+}
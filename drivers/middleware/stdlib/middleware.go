@@ -1,9 +1,11 @@
 package stdlib
 
 import (
+	"context"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
 )
@@ -13,6 +15,14 @@ type Middleware struct {
 	OnError        ErrorHandler
 	OnLimitReached LimitReachedHandler
 	KeyGetter      KeyGetter
+	TokenRegistry  limiter.TokenRegistry
+	Rules          []Rule
+	HeaderStyle    HeaderStyle
+	FailurePolicy  FailurePolicy
+	Fallback       limiter.Store
+	StoreTimeout   time.Duration
+	CostFunc       CostFunc
+	mutex          sync.RWMutex
 }
 
 func NewMiddleware(limiter *limiter.Limiter, options ...Option) *Middleware {
@@ -21,6 +31,8 @@ func NewMiddleware(limiter *limiter.Limiter, options ...Option) *Middleware {
 		OnError:        WithDefaultErrorHandler,
 		OnLimitReached: WithDefaultLimitReachedHandler,
 		KeyGetter:      WithIPKeyGetter(limiter),
+		HeaderStyle:    HeaderStyleLegacy,
+		FailurePolicy:  FailClosed,
 	}
 
 	for _, option := range options {
@@ -32,28 +44,212 @@ func NewMiddleware(limiter *limiter.Limiter, options ...Option) *Middleware {
 
 func (middleware *Middleware) Handler(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		key := middleware.KeyGetter(r)
-
-		if strings.TrimSpace(key) == "" {
+		rule, key, err := middleware.getRuleAndKey(r)
+		if err == nil && key == "" {
 			h.ServeHTTP(w, r)
 			return
 		}
 
-		context, err := middleware.Limiter.Get(r.Context(), key)
+		var context limiter.Context
+		if err == nil {
+			context, err = middleware.getContext(r, key, rule)
+		} else if middleware.FailurePolicy == FailFallback && middleware.Fallback != nil {
+			// The token registry failed: the request is limited by the rate of its rule instead, in the
+			// store unless it fails too.
+			context, err = middleware.getContext(r, key, rule)
+		}
 		if err != nil {
+			if middleware.FailurePolicy == FailOpen {
+				h.ServeHTTP(w, r)
+				return
+			}
 			middleware.OnError(w, r, err)
 			return
 		}
 
-		w.Header().Add("X-RateLimit-Limit", strconv.FormatInt(context.Limit, 10))
-		w.Header().Add("X-RateLimit-Remaining", strconv.FormatInt(context.Remaining, 10))
-		w.Header().Add("X-RateLimit-Reset", strconv.FormatInt(context.Reset, 10))
+		now := time.Now()
+		setHeaders(w, middleware.HeaderStyle, rule, context, now)
 
-		if context.Reached {
+		if context.Reached || context.BlockedUntil > 0 {
+			setRetryAfter(w, context, now)
 			middleware.OnLimitReached(w, r)
 			return
 		}
 
-		//h.ServeHTTP(w, r)
+		h.ServeHTTP(w, r)
 	})
 }
+
+// getContext increments the key in the store by the cost of the request, within the store timeout.
+// If the store fails and the failure policy is FailFallback, the key is incremented in the fallback
+// store instead.
+func (middleware *Middleware) getContext(r *http.Request, key string, rule Rule) (limiter.Context, error) {
+	ctx := r.Context()
+	if middleware.StoreTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, middleware.StoreTimeout)
+		defer cancel()
+	}
+
+	cost := middleware.getCost(r)
+	lctx, err := increment(ctx, middleware.GetLimiter().Store, key, cost, rule)
+	if err == nil || middleware.FailurePolicy != FailFallback || middleware.Fallback == nil {
+		return lctx, err
+	}
+
+	return increment(r.Context(), middleware.Fallback, key, cost, rule)
+}
+
+func (middleware *Middleware) getCost(r *http.Request) int64 {
+	if middleware.CostFunc == nil {
+		return 1
+	}
+	return middleware.CostFunc(r)
+}
+
+// increment increments the key by cost, for every rate of the rule. A request without cost is only
+// rejected once the limit is reached, without consuming it. The stores do not count a request whose
+// cost exceeds the remaining quota, which is kept for cheaper requests.
+func increment(ctx context.Context, store limiter.Store, key string, cost int64,
+	rule Rule) (limiter.Context, error) {
+
+	limiter := rule.newLimiter(store)
+	if cost <= 0 {
+		return limiter.Peek(ctx, key)
+	}
+	return limiter.Inc(ctx, key, cost)
+}
+
+// getRuleAndKey returns the rule limiting the request and its key, or an empty key if the request
+// is not limited. With rules, the first rule returning a key applies and namespaces it by its name.
+// Without rules, the request is limited by the rates of the limiter. If the token registry fails, the
+// rule is returned with its own rate, together with the error.
+func (middleware *Middleware) getRuleAndKey(r *http.Request) (Rule, string, error) {
+	rules, limiter := middleware.getRulesAndLimiter()
+	if len(rules) == 0 {
+		key := middleware.KeyGetter(r)
+		if strings.TrimSpace(key) == "" {
+			return Rule{}, "", nil
+		}
+
+		rate, err := middleware.getRate(r, key, limiter.Rate, limiter.Rates)
+		return NewRule(DefaultPolicyName, middleware.KeyGetter, rate, limiter.Rates...), key, err
+	}
+
+	for _, rule := range rules {
+		key := rule.KeyGetter(r)
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+
+		rate, err := middleware.getRate(r, key, inheritRate(rule.Rate, limiter.Rate), rule.Rates)
+		rule.Rate = rate
+		return rule, rule.Name + ":" + key, err
+	}
+
+	return Rule{}, "", nil
+}
+
+// SetRules replaces the rules of the middleware. Requests being handled keep the rules they started with.
+func (middleware *Middleware) SetRules(rules ...Rule) {
+	middleware.mutex.Lock()
+	defer middleware.mutex.Unlock()
+	middleware.Rules = rules
+}
+
+func (middleware *Middleware) GetRules() []Rule {
+	middleware.mutex.RLock()
+	defer middleware.mutex.RUnlock()
+	return middleware.Rules
+}
+
+// SetRates replaces the rates of the limiter, which limit the requests without rules and whose
+// algorithm is inherited by the rules. The limiter is copied, so requests being handled keep the rates
+// they started with.
+func (middleware *Middleware) SetRates(rate limiter.Rate, rates ...limiter.Rate) {
+	middleware.mutex.Lock()
+	defer middleware.mutex.Unlock()
+	middleware.setRates(rate, rates)
+}
+
+// SetRatesAndRules replaces both the rates of the limiter and the rules of the middleware at once, so
+// no request is limited by the new rules with the old rates, or the other way around.
+func (middleware *Middleware) SetRatesAndRules(rules []Rule, rate limiter.Rate, rates ...limiter.Rate) {
+	middleware.mutex.Lock()
+	defer middleware.mutex.Unlock()
+	middleware.setRates(rate, rates)
+	middleware.Rules = rules
+}
+
+func (middleware *Middleware) setRates(rate limiter.Rate, rates []limiter.Rate) {
+	limiter := *middleware.Limiter
+	limiter.Rate = rate
+	limiter.Rates = rates
+	middleware.Limiter = &limiter
+}
+
+func (middleware *Middleware) GetLimiter() *limiter.Limiter {
+	middleware.mutex.RLock()
+	defer middleware.mutex.RUnlock()
+	return middleware.Limiter
+}
+
+// getRulesAndLimiter returns the rules and the limiter of the middleware, as swapped together.
+func (middleware *Middleware) getRulesAndLimiter() ([]Rule, *limiter.Limiter) {
+	middleware.mutex.RLock()
+	defer middleware.mutex.RUnlock()
+	return middleware.Rules, middleware.Limiter
+}
+
+// getRate returns the rate of the request limited by given key: the rate registered for its token,
+// if any and if the request is keyed by its token, otherwise the given rate. Rules keyed by IP, header
+// or globally keep their own rate.
+func (middleware *Middleware) getRate(r *http.Request, key string, rate limiter.Rate,
+	rates []limiter.Rate) (limiter.Rate, error) {
+
+	if middleware.TokenRegistry == nil {
+		return rate, nil
+	}
+
+	token := strings.TrimSpace(middleware.GetLimiter().GetToken(r))
+	if token == "" || token != strings.TrimSpace(key) {
+		return rate, nil
+	}
+
+	return middleware.getTokenRate(r.Context(), token, rate, rates)
+}
+
+// getTokenRate returns the rate registered for the token, if any, otherwise the given rate. Additional
+// rates, such as a quota, are only checked together with a fixed window rate without block: the
+// registered rate is turned into one when they are given.
+func (middleware *Middleware) getTokenRate(ctx context.Context, token string, rate limiter.Rate,
+	rates []limiter.Rate) (limiter.Rate, error) {
+
+	if middleware.TokenRegistry == nil || token == "" {
+		return rate, nil
+	}
+
+	tokenRate, ok, err := middleware.TokenRegistry.Get(ctx, token)
+	if err != nil || !ok {
+		return rate, err
+	}
+
+	tokenRate = inheritRate(tokenRate, rate)
+	if len(rates) > 0 {
+		tokenRate.Algorithm = limiter.FixedWindow
+		tokenRate.Block = 0
+	}
+
+	return tokenRate, nil
+}
+
+// inheritRate fills the algorithm and the block duration of rate from parent, when unset.
+func inheritRate(rate limiter.Rate, parent limiter.Rate) limiter.Rate {
+	if rate.Algorithm == "" {
+		rate.Algorithm = parent.Algorithm
+	}
+	if rate.Block == 0 {
+		rate.Block = parent.Block
+	}
+	return rate
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -13,6 +14,8 @@ import (
 
 	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
 	stdlib "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/middleware/stdlib"
+	mregistry "github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/memory"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
 	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/redis"
 	libredis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
@@ -449,6 +452,1020 @@ func TestRateLimiterByTokenAndIPWithConcurrentAccess(t *testing.T) {
 	is.Equal(successByToken, atomic.LoadInt64(&counterByToken))
 }
 
+func TestRateLimiterWithBlockDuration(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	is.NotNil(request)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:block-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  2,
+		Period: 100 * time.Millisecond,
+	}, limiter.WithBlockDuration(1*time.Minute))
+
+	middleware := stdlib.NewMiddleware(limiter).Handler(handler)
+	is.NotZero(middleware)
+
+	for i := 1; i <= 3; i++ {
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, request)
+
+		if i <= 2 {
+			is.Equal(http.StatusOK, resp.Code)
+		} else {
+			is.Equal(http.StatusTooManyRequests, resp.Code)
+		}
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+}
+
+func TestRateLimiterWithHeaderStyle(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	is.NotNil(request)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:header-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	})
+
+	legacy := stdlib.NewMiddleware(limiter).Handler(handler)
+	resp := httptest.NewRecorder()
+	legacy.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Equal("2", resp.Header().Get("X-RateLimit-Limit"))
+	is.Equal("1", resp.Header().Get("X-RateLimit-Remaining"))
+	is.Empty(resp.Header().Get("RateLimit"))
+	is.Empty(resp.Header().Get("RateLimit-Policy"))
+
+	ietf := stdlib.NewMiddleware(limiter, stdlib.WithHeaderStyle(stdlib.HeaderStyleIETF)).Handler(handler)
+	resp = httptest.NewRecorder()
+	ietf.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Empty(resp.Header().Get("X-RateLimit-Limit"))
+	is.Equal(`"default";q=2;w=60`, resp.Header().Get("RateLimit-Policy"))
+	is.Regexp(`^"default";r=0;t=(59|60)$`, resp.Header().Get("RateLimit"))
+	is.Empty(resp.Header().Get("Retry-After"))
+
+	both := stdlib.NewMiddleware(limiter, stdlib.WithHeaderStyle(stdlib.HeaderStyleBoth)).Handler(handler)
+	resp = httptest.NewRecorder()
+	both.ServeHTTP(resp, request)
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+	is.Equal("0", resp.Header().Get("X-RateLimit-Remaining"))
+	is.Equal(`"default";q=2;w=60`, resp.Header().Get("RateLimit-Policy"))
+	is.Regexp(`^(59|60)$`, resp.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterWithCostFunc(t *testing.T) {
+	for _, algorithm := range []limiter.Algorithm{limiter.FixedWindow, limiter.SlidingLog} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			is := require.New(t)
+
+			handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := w.Write([]byte("hello"))
+				if err != nil {
+					t.Fatal(err)
+				}
+			})
+
+			store := memory.NewStoreWithOptions(limiter.StoreOptions{
+				Prefix:          "limiter:memory:cost-test",
+				CleanUpInterval: 30 * time.Second,
+			})
+
+			limiter := limiter.NewLimiter(store, limiter.Rate{
+				Limit:  10,
+				Period: 1 * time.Minute,
+			}, limiter.WithAlgorithm(algorithm))
+
+			middleware := stdlib.NewMiddleware(limiter,
+				stdlib.WithCostFunc(stdlib.WithBodySizeCost(1000)),
+			).Handler(handler)
+
+			newRequest := func(size int) *http.Request {
+				request, err := http.NewRequest("POST", "/", strings.NewReader(strings.Repeat("a", size)))
+				is.NoError(err)
+				request.RemoteAddr = "192.168.0.1:8080"
+				return request
+			}
+
+			// A 3500 bytes body costs 4.
+			for _, remaining := range []string{"6", "2"} {
+				resp := httptest.NewRecorder()
+				middleware.ServeHTTP(resp, newRequest(3500))
+				is.Equal(http.StatusOK, resp.Code)
+				is.Equal(remaining, resp.Header().Get("X-RateLimit-Remaining"))
+			}
+
+			// The cost exceeds the remaining quota, which is not consumed and is kept for cheaper
+			// requests.
+			for i := 0; i < 2; i++ {
+				resp := httptest.NewRecorder()
+				middleware.ServeHTTP(resp, newRequest(3500))
+				is.Equal(http.StatusTooManyRequests, resp.Code)
+			}
+
+			for _, remaining := range []string{"1", "0"} {
+				resp := httptest.NewRecorder()
+				middleware.ServeHTTP(resp, newRequest(10))
+				is.Equal(http.StatusOK, resp.Code)
+				is.Equal(remaining, resp.Header().Get("X-RateLimit-Remaining"))
+			}
+		})
+	}
+}
+
+func TestRateLimiterWithMultipleRates(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:multi-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  10,
+		Period: 1 * time.Second,
+	}, limiter.WithRates(limiter.Rate{
+		Limit:  3,
+		Period: 1 * time.Minute,
+	}))
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithHeaderStyle(stdlib.HeaderStyleIETF),
+	).Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	// The minute rate is the most restrictive one, and is reported in the headers.
+	for _, remaining := range []string{"2", "1", "0"} {
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, request)
+		is.Equal(http.StatusOK, resp.Code)
+		is.Equal(`"default";q=3;w=60`, resp.Header().Get("RateLimit-Policy"))
+		is.Contains(resp.Header().Get("RateLimit"), ";r="+remaining+";")
+	}
+
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+	is.NotEmpty(resp.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterWithMultipleRatesOfSameLimit(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:multi-same-limit-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  3,
+		Period: 1 * time.Minute,
+	}, limiter.WithRates(limiter.Rate{
+		Limit:  3,
+		Period: 1 * time.Second,
+	}))
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithHeaderStyle(stdlib.HeaderStyleIETF),
+	).Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	// Both rates have the same remaining quota, the minute rate resets last and is reported.
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Equal(`"default";q=3;w=60`, resp.Header().Get("RateLimit-Policy"))
+}
+
+func TestRateLimiterWithGCRAHeaders(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:gcra-headers-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:     10,
+		Period:    1 * time.Minute,
+		Burst:     2,
+		Algorithm: limiter.GCRA,
+	})
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithHeaderStyle(stdlib.HeaderStyleIETF),
+	).Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	// The burst of 2 requests is refilled in 2 emission intervals of 6 seconds.
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Equal(`"default";q=2;w=12`, resp.Header().Get("RateLimit-Policy"))
+}
+
+func TestRateLimiterWithTokenRegistry(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:registry-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	registry := mregistry.NewRegistry(map[string]limiter.Rate{
+		"premium-api-key": {
+			Limit:  5,
+			Period: 1 * time.Minute,
+		},
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	})
+
+	middleware := stdlib.NewMiddleware(
+		limiter,
+		stdlib.WithKeyGetter(stdlib.WithTokenKeyGetter(limiter)),
+		stdlib.WithTokenRegistry(registry),
+	).Handler(handler)
+	is.NotZero(middleware)
+
+	tokens := map[string]int64{
+		"free-api-key":    2,
+		"premium-api-key": 5,
+	}
+
+	for token, success := range tokens {
+		request, err := http.NewRequest("GET", "/", nil)
+		request.Header.Set("API_KEY", token)
+		is.NoError(err)
+		is.NotNil(request)
+
+		for i := int64(1); i <= 10; i++ {
+			resp := httptest.NewRecorder()
+			middleware.ServeHTTP(resp, request)
+
+			if i <= success {
+				is.Equal(http.StatusOK, resp.Code)
+			} else {
+				is.Equal(http.StatusTooManyRequests, resp.Code)
+			}
+		}
+	}
+}
+
+func TestRateLimiterWithTokenRegistryAndRules(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:registry-rules-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	registry := mregistry.NewRegistry(map[string]limiter.Rate{
+		"premium-api-key": {
+			Limit:  5,
+			Period: 1 * time.Minute,
+		},
+	})
+
+	rate := limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	}
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	// The rule by IP and the global rule apply to requests carrying a token, which must not raise
+	// their limits.
+	middleware := stdlib.NewMiddleware(
+		limiter,
+		stdlib.WithRules(
+			stdlib.NewRule("login", stdlib.WithMatchers(stdlib.WithGlobalKeyGetter(), stdlib.MatchPath("/login")), rate),
+			stdlib.NewRule("upload", stdlib.WithMatchers(
+				func(r *http.Request) string { return limiter.GetIP(r).String() },
+				stdlib.MatchPath("/upload"),
+			), rate),
+			stdlib.NewRule("token", stdlib.WithTokenKeyGetter(limiter), rate),
+		),
+		stdlib.WithTokenRegistry(registry),
+	).Handler(handler)
+	is.NotZero(middleware)
+
+	paths := map[string]int64{
+		"/login":  2,
+		"/upload": 2,
+		"/":       5,
+	}
+
+	for path, success := range paths {
+		request, err := http.NewRequest("GET", path, nil)
+		is.NoError(err)
+		request.RemoteAddr = "192.168.0.1:8080"
+		request.Header.Set("API_KEY", "premium-api-key")
+
+		for i := int64(1); i <= 10; i++ {
+			resp := httptest.NewRecorder()
+			middleware.ServeHTTP(resp, request)
+
+			if i <= success {
+				is.Equal(http.StatusOK, resp.Code, path)
+			} else {
+				is.Equal(http.StatusTooManyRequests, resp.Code, path)
+			}
+		}
+	}
+}
+
+func TestRateLimiterWithTokenRegistryAndQuota(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:registry-quota-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	// Registered rates of other algorithms are enforced as fixed windows together with the quota.
+	registry := mregistry.NewRegistry(map[string]limiter.Rate{
+		"premium-api-key": {
+			Limit:     5,
+			Period:    1 * time.Minute,
+			Burst:     5,
+			Algorithm: limiter.GCRA,
+		},
+		"sliding-api-key": {
+			Limit:     5,
+			Period:    1 * time.Minute,
+			Algorithm: limiter.SlidingWindow,
+		},
+	})
+
+	rate := limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	}
+	quota := limiter.NewCalendarRate(3, limiter.CalendarDay, time.UTC)
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(
+		limiter,
+		stdlib.WithRules(stdlib.NewRule("token", stdlib.WithTokenKeyGetter(limiter), rate, quota)),
+		stdlib.WithTokenRegistry(registry),
+	).Handler(handler)
+	is.NotZero(middleware)
+
+	tokens := map[string]int64{
+		"premium-api-key": 3,
+		"sliding-api-key": 3,
+		"any-api-key":     2,
+	}
+
+	for token, success := range tokens {
+		request, err := http.NewRequest("GET", "/", nil)
+		is.NoError(err)
+		request.RemoteAddr = "192.168.0.1:8080"
+		request.Header.Set("API_KEY", token)
+
+		for i := int64(1); i <= 5; i++ {
+			resp := httptest.NewRecorder()
+			middleware.ServeHTTP(resp, request)
+
+			if i <= success {
+				is.Equal(http.StatusOK, resp.Code, token)
+			} else {
+				is.Equal(http.StatusTooManyRequests, resp.Code, token)
+			}
+		}
+	}
+}
+
+type countingStore struct {
+	limiter.Store
+	calls int64
+}
+
+func (store *countingStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	atomic.AddInt64(&store.calls, 1)
+	return store.Store.Get(ctx, key, rate)
+}
+
+func TestRateLimiterWithRules(t *testing.T) {
+	apiKey := "any-api-key"
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := &countingStore{
+		Store: memory.NewStoreWithOptions(limiter.StoreOptions{
+			Prefix:          "limiter:memory:rules-test",
+			CleanUpInterval: 30 * time.Second,
+		}),
+	}
+
+	rateByIP := limiter.Rate{
+		Limit:  10,
+		Period: 1 * time.Minute,
+	}
+
+	rateByToken := limiter.Rate{
+		Limit:  15,
+		Period: 1 * time.Minute,
+	}
+
+	limiter := limiter.NewLimiter(store, rateByIP)
+
+	middleware := stdlib.NewMiddleware(
+		limiter,
+		stdlib.WithRules(
+			stdlib.NewRule("token", stdlib.WithTokenKeyGetter(limiter), rateByToken),
+			stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), rateByIP),
+		),
+	).Handler(handler)
+	is.NotZero(middleware)
+
+	requestByIP, err := http.NewRequest("GET", "/", nil)
+	requestByIP.RemoteAddr = "192.168.0.1:8080"
+	is.NoError(err)
+	is.NotNil(requestByIP)
+
+	requestByToken, err := http.NewRequest("GET", "/", nil)
+	requestByToken.RemoteAddr = "192.168.0.1:8080"
+	requestByToken.Header.Set("API_KEY", apiKey)
+	is.NoError(err)
+	is.NotNil(requestByToken)
+
+	successByIP := int64(10)
+	successByToken := int64(15)
+	clients := int64(20)
+
+	for i := int64(1); i <= clients; i++ {
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, requestByToken)
+
+		if i <= successByToken {
+			is.Equal(http.StatusOK, resp.Code)
+			is.Equal("hello", resp.Body.String())
+			is.Equal("15", resp.Header().Get("X-RateLimit-Limit"))
+		} else {
+			is.Equal(http.StatusTooManyRequests, resp.Code)
+		}
+	}
+
+	for i := int64(1); i <= clients; i++ {
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, requestByIP)
+
+		if i <= successByIP {
+			is.Equal(http.StatusOK, resp.Code)
+			is.Equal("10", resp.Header().Get("X-RateLimit-Limit"))
+		} else {
+			is.Equal(http.StatusTooManyRequests, resp.Code)
+		}
+	}
+
+	is.Equal(2*clients, atomic.LoadInt64(&store.calls))
+}
+
+type unavailableStore struct {
+	limiter.Store
+}
+
+// Get waits for the request to time out, as a store which is not responding.
+func (store *unavailableStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	<-ctx.Done()
+	return limiter.Context{}, ctx.Err()
+}
+
+func TestRateLimiterWithMatchers(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:matchers-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rateByLogin := limiter.Rate{Limit: 1, Period: time.Minute}
+	rateByTenant := limiter.Rate{Limit: 2, Period: time.Minute}
+	rateByIP := limiter.Rate{Limit: 3, Period: time.Minute}
+
+	limiter := limiter.NewLimiter(store, rateByIP)
+
+	middleware := stdlib.NewMiddleware(
+		limiter,
+		stdlib.WithRules(
+			stdlib.NewRule("login", stdlib.WithMatchers(stdlib.WithClientIPKeyGetter(limiter),
+				stdlib.MatchPath("/login"),
+				stdlib.MatchMethods("POST"),
+			), rateByLogin),
+			stdlib.NewRule("tenant", stdlib.WithMatchers(stdlib.WithHeaderKeyGetter("X-Tenant"),
+				stdlib.MatchPath("/api/**"),
+				stdlib.MatchHost("*.example.com"),
+				stdlib.MatchHeader("X-Tenant", "*"),
+			), rateByTenant),
+			stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), rateByIP),
+		),
+	).Handler(handler)
+
+	newRequest := func(method string, url string, tenant string) *http.Request {
+		request, err := http.NewRequest(method, url, nil)
+		is.NoError(err)
+		request.RemoteAddr = "192.168.0.1:8080"
+		if tenant != "" {
+			request.Header.Set("X-Tenant", tenant)
+		}
+		return request
+	}
+
+	expected := []struct {
+		request *http.Request
+		limit   string
+	}{
+		{newRequest("POST", "/login", ""), "1"},
+		{newRequest("GET", "/login", ""), "3"},
+		{newRequest("GET", "http://api.example.com:8080/api/orders/1", "acme"), "2"},
+		{newRequest("GET", "http://api.example.com/api", "acme"), "2"},
+		{newRequest("GET", "http://api.example.com/apis", "acme"), "3"},
+		{newRequest("GET", "http://example.com/api/orders", "acme"), "3"},
+		{newRequest("GET", "http://api.example.com/api/orders", ""), "3"},
+	}
+	for _, e := range expected {
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, e.request)
+		is.Equal(e.limit, resp.Header().Get("X-RateLimit-Limit"), e.request.URL.String())
+	}
+
+	// Tenants are limited separately.
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, newRequest("GET", "http://api.example.com/api/orders", "acme"))
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+
+	resp = httptest.NewRecorder()
+	middleware.ServeHTTP(resp, newRequest("GET", "http://api.example.com/api/orders", "other"))
+	is.Equal(http.StatusOK, resp.Code)
+
+	// A token does not bypass the login rule, keyed by the client IP.
+	request := newRequest("POST", "/login", "")
+	request.Header.Set("API_KEY", "token")
+	resp = httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+}
+
+func TestRateLimiterWithSetRules(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:set-rules-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{Limit: 1, Period: time.Minute}
+	reloadedRate := limiter.Rate{Limit: 5, Period: time.Minute}
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithRules(stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), rate)),
+	)
+	handle := middleware.Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	for _, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp := httptest.NewRecorder()
+		handle.ServeHTTP(resp, request)
+		is.Equal(code, resp.Code)
+	}
+
+	// The counter of the rule is kept, but the new rate applies to the running handler.
+	middleware.SetRules(stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), reloadedRate))
+	is.Len(middleware.GetRules(), 1)
+
+	resp := httptest.NewRecorder()
+	handle.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Equal("5", resp.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimiterWithSetRates(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:set-rates-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{Limit: 1, Period: time.Minute}
+	reloadedRate := limiter.Rate{Limit: 5, Period: time.Minute}
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(limiter)
+	handle := middleware.Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	for _, code := range []int{http.StatusOK, http.StatusTooManyRequests} {
+		resp := httptest.NewRecorder()
+		handle.ServeHTTP(resp, request)
+		is.Equal(code, resp.Code)
+	}
+
+	// Without rules, the new rate applies to the running handler, and the limiter given to the
+	// middleware is left untouched.
+	middleware.SetRates(reloadedRate)
+	is.Equal(reloadedRate, middleware.GetLimiter().Rate)
+	is.Equal(rate, limiter.Rate)
+
+	resp := httptest.NewRecorder()
+	handle.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+	is.Equal("5", resp.Header().Get("X-RateLimit-Limit"))
+}
+
+func TestRateLimiterWithSetRatesAndRules(t *testing.T) {
+	is := require.New(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:set-rates-and-rules-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	rate := limiter.Rate{Limit: 1, Period: time.Minute}
+	reloadedRate := limiter.Rate{Limit: 5, Period: time.Minute, Algorithm: limiter.SlidingWindow}
+	ruleRate := limiter.Rate{Limit: 3, Period: time.Minute}
+
+	limiter := limiter.NewLimiter(store, rate)
+
+	middleware := stdlib.NewMiddleware(limiter,
+		stdlib.WithRules(stdlib.NewRule("ip", stdlib.WithIPKeyGetter(limiter), rate)),
+	)
+	handle := middleware.Handler(handler)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.RemoteAddr = "192.168.0.1:8080"
+
+	// The rules and the rate of the limiter, whose algorithm they inherit, are swapped together.
+	middleware.SetRatesAndRules([]stdlib.Rule{
+		stdlib.NewRule("reloaded", stdlib.WithIPKeyGetter(limiter), ruleRate),
+	}, reloadedRate)
+	is.Len(middleware.GetRules(), 1)
+	is.Equal(reloadedRate, middleware.GetLimiter().Rate)
+
+	for i := 1; i <= 4; i++ {
+		resp := httptest.NewRecorder()
+		handle.ServeHTTP(resp, request)
+		if i <= 3 {
+			is.Equal(http.StatusOK, resp.Code)
+		} else {
+			is.Equal(http.StatusTooManyRequests, resp.Code)
+		}
+		is.Equal("3", resp.Header().Get("X-RateLimit-Limit"))
+	}
+}
+
+func TestRateLimiterWithFailurePolicy(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	is.NotNil(request)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	fallback := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:fallback-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(&unavailableStore{}, limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	})
+
+	tests := []struct {
+		name     string
+		options  []stdlib.Option
+		expected []int
+	}{
+		{
+			name:     "default",
+			options:  nil,
+			expected: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		},
+		{
+			name:     "open",
+			options:  []stdlib.Option{stdlib.WithFailurePolicy(stdlib.FailOpen)},
+			expected: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:     "fallback",
+			options:  []stdlib.Option{stdlib.WithFallback(fallback)},
+			expected: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := require.New(t)
+
+			options := append(tt.options, stdlib.WithStoreTimeout(10*time.Millisecond))
+			middleware := stdlib.NewMiddleware(limiter, options...).Handler(handler)
+
+			for _, code := range tt.expected {
+				resp := httptest.NewRecorder()
+				middleware.ServeHTTP(resp, request)
+				is.Equal(code, resp.Code)
+			}
+		})
+	}
+}
+
+type unavailableRegistry struct{}
+
+func (registry unavailableRegistry) Get(ctx context.Context, token string) (limiter.Rate, bool, error) {
+	return limiter.Rate{}, false, limiter.ErrStoreUnavailable
+}
+
+func TestRateLimiterWithFailurePolicyAndTokenRegistry(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	is.NoError(err)
+	request.Header.Set("API_KEY", "any-api-key")
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:registry-failure-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	fallback := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:registry-fallback-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  2,
+		Period: 1 * time.Minute,
+	})
+
+	tests := []struct {
+		name     string
+		options  []stdlib.Option
+		expected []int
+	}{
+		{
+			name:     "default",
+			options:  nil,
+			expected: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable},
+		},
+		{
+			name:     "open",
+			options:  []stdlib.Option{stdlib.WithFailurePolicy(stdlib.FailOpen)},
+			expected: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:     "fallback",
+			options:  []stdlib.Option{stdlib.WithFallback(fallback)},
+			expected: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			is := require.New(t)
+
+			options := append(tt.options,
+				stdlib.WithKeyGetter(stdlib.WithTokenKeyGetter(limiter)),
+				stdlib.WithTokenRegistry(unavailableRegistry{}),
+			)
+			middleware := stdlib.NewMiddleware(limiter, options...).Handler(handler)
+
+			for _, code := range tt.expected {
+				resp := httptest.NewRecorder()
+				middleware.ServeHTTP(resp, request)
+				is.Equal(code, resp.Code)
+			}
+		})
+	}
+
+	// The store is healthy: the requests are counted in the store rather than in the fallback.
+	ctx := context.Background()
+	lctx, err := store.Peek(ctx, "any-api-key", limiter.Rate)
+	is.NoError(err)
+	is.Equal(int64(0), lctx.Remaining)
+
+	lctx, err = fallback.Peek(ctx, "any-api-key", limiter.Rate)
+	is.NoError(err)
+	is.Equal(int64(2), lctx.Remaining)
+}
+
+func TestConcurrencyMiddleware(t *testing.T) {
+	is := require.New(t)
+
+	request, err := http.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "192.168.0.1:8080"
+	is.NoError(err)
+	is.NotNil(request)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+	})
+
+	store := memory.NewConcurrencyStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:concurrency-middleware-test",
+		CleanUpInterval: 30 * time.Second,
+	})
+
+	limiter := limiter.NewConcurrencyLimiter(store, 2, 1*time.Minute)
+	// The requests are keyed by the IP address of the connection.
+	middleware := stdlib.NewConcurrencyMiddleware(limiter,
+		stdlib.WithLimitReachedHandler(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "too many exports in progress", http.StatusTooManyRequests)
+		}),
+	).Handler(handler)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			resp := httptest.NewRecorder()
+			middleware.ServeHTTP(resp, request)
+			is.Equal(http.StatusOK, resp.Code)
+		}()
+		<-entered
+	}
+
+	resp := httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusTooManyRequests, resp.Code)
+	is.Equal("2", resp.Header().Get("X-Concurrency-Limit"))
+	is.Equal("too many exports in progress\n", resp.Body.String())
+
+	// Another client has its own slots.
+	other := request.Clone(request.Context())
+	other.RemoteAddr = "192.168.0.2:8080"
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp := httptest.NewRecorder()
+		middleware.ServeHTTP(resp, other)
+		is.Equal(http.StatusOK, resp.Code)
+	}()
+	<-entered
+
+	close(release)
+	wg.Wait()
+
+	go func() {
+		<-entered
+	}()
+	resp = httptest.NewRecorder()
+	middleware.ServeHTTP(resp, request)
+	is.Equal(http.StatusOK, resp.Code)
+}
+
 func newRedisClient(redisURL string) (*libredis.Client, error) {
 	url := fmt.Sprintf("%s/0", redisURL)
 
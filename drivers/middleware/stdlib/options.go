@@ -3,6 +3,7 @@ package stdlib
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
 )
@@ -25,8 +26,9 @@ func WithErrorHandler(h ErrorHandler) Option {
 	})
 }
 
+// WithDefaultErrorHandler rejects the request with 503 Service Unavailable.
 func WithDefaultErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
-	panic(err)
+	http.Error(w, "the rate limiter is temporarily unavailable", http.StatusServiceUnavailable)
 }
 
 type LimitReachedHandler func(w http.ResponseWriter, r *http.Request)
@@ -49,6 +51,84 @@ func WithKeyGetter(h KeyGetter) Option {
 	})
 }
 
+func WithTokenRegistry(registry limiter.TokenRegistry) Option {
+	return option(func(m *Middleware) {
+		m.TokenRegistry = registry
+	})
+}
+
+// WithRules evaluates the rules in order for each request, and limits the request by the first
+// matching one with a single store call. The key getter of the middleware is ignored.
+func WithRules(rules ...Rule) Option {
+	return option(func(m *Middleware) {
+		m.Rules = rules
+	})
+}
+
+// FailurePolicy defines how requests are handled when the store fails.
+type FailurePolicy string
+
+const (
+	// FailClosed rejects the request through the error handler.
+	FailClosed FailurePolicy = "closed"
+	// FailOpen lets the request through, without limiting it.
+	FailOpen FailurePolicy = "open"
+	// FailFallback limits the request with the fallback store, usually an in-memory one.
+	FailFallback FailurePolicy = "fallback"
+)
+
+// WithFailurePolicy selects how requests are handled when the store fails. Defaults to FailClosed.
+func WithFailurePolicy(policy FailurePolicy) Option {
+	return option(func(m *Middleware) {
+		m.FailurePolicy = policy
+	})
+}
+
+// WithFallback limits requests with the given store, such as memory.NewStore(), while the store
+// of the limiter fails.
+func WithFallback(store limiter.Store) Option {
+	return option(func(m *Middleware) {
+		m.FailurePolicy = FailFallback
+		m.Fallback = store
+	})
+}
+
+// WithStoreTimeout bounds the duration of each store call.
+func WithStoreTimeout(timeout time.Duration) Option {
+	return option(func(m *Middleware) {
+		m.StoreTimeout = timeout
+	})
+}
+
+// CostFunc returns the quota consumed by a request. A request is rejected when its cost exceeds
+// the remaining quota.
+type CostFunc func(r *http.Request) int64
+
+// WithCostFunc makes requests consume the quota returned by f, instead of 1.
+func WithCostFunc(f CostFunc) Option {
+	return option(func(m *Middleware) {
+		m.CostFunc = f
+	})
+}
+
+// WithBodySizeCost makes requests consume one unit of quota per started size bytes of their body,
+// and at least one. Requests of unknown length consume one unit.
+func WithBodySizeCost(size int64) CostFunc {
+	return func(r *http.Request) int64 {
+		if size <= 0 || r.ContentLength <= 0 {
+			return 1
+		}
+		return (r.ContentLength + size - 1) / size
+	}
+}
+
+// WithHeaderStyle selects the rate limit headers written on responses. Defaults to HeaderStyleLegacy.
+func WithHeaderStyle(style HeaderStyle) Option {
+	return option(func(m *Middleware) {
+		m.HeaderStyle = style
+	})
+}
+
 func WithIPKeyGetter(l *limiter.Limiter) func(r *http.Request) string {
 	return func(r *http.Request) string {
 		if strings.TrimSpace(l.GetToken(r)) != "" {
@@ -59,22 +139,42 @@ func WithIPKeyGetter(l *limiter.Limiter) func(r *http.Request) string {
 	}
 }
 
+// WithClientIPKeyGetter returns the client IP as key, whether or not the request carries a token.
+func WithClientIPKeyGetter(l *limiter.Limiter) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return l.GetIP(r).String()
+	}
+}
+
 func WithTokenKeyGetter(l *limiter.Limiter) func(r *http.Request) string {
 	return func(r *http.Request) string {
 		return l.GetToken(r)
 	}
 }
 
+// WithHeaderKeyGetter returns the value of given request header as key.
+func WithHeaderKeyGetter(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// WithGlobalKeyGetter returns the same key for every request, so they share a single counter.
+func WithGlobalKeyGetter() func(r *http.Request) string {
+	return func(r *http.Request) string {
+		return "global"
+	}
+}
+
 func WithTokenAndIPKeyGetter(l *limiter.Limiter) func(r *http.Request) string {
 	return func(r *http.Request) string {
 		apiKey := l.GetToken(r)
 
 		if apiKey != "" {
-			// TODO: personalizar limit por token
 			return apiKey
 		}
 
 		// TODO: personalizar limit por IP
-		return limiter.GetIP(r).String()
+		return l.GetIP(r).String()
 	}
 }
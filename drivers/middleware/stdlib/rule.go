@@ -0,0 +1,30 @@
+package stdlib
+
+import (
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// Rule limits the requests for which its key getter returns a non-empty key to its rate, and to its
+// additional rates, if any. Its name namespaces the keys, so rules sharing a store never share counters.
+type Rule struct {
+	Name      string
+	KeyGetter KeyGetter
+	Rate      limiter.Rate
+	Rates     []limiter.Rate
+}
+
+func NewRule(name string, keyGetter KeyGetter, rate limiter.Rate, rates ...limiter.Rate) Rule {
+	return Rule{
+		Name:      name,
+		KeyGetter: keyGetter,
+		Rate:      rate,
+		Rates:     rates,
+	}
+}
+
+// newLimiter returns a limiter enforcing the rates of the rule in the store.
+func (rule Rule) newLimiter(store limiter.Store) *limiter.Limiter {
+	limiter := limiter.NewLimiter(store, rule.Rate)
+	limiter.Rates = rule.Rates
+	return limiter
+}
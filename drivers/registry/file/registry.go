@@ -0,0 +1,81 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/memory"
+)
+
+// Entry is the rate of a token, as written in the registry file:
+//
+//	{
+//		"abc123": {"limit": 100, "period_seconds": 60},
+//		"premium": {"limit": 1000, "period_seconds": 60, "burst": 50, "algorithm": "gcra"}
+//	}
+type Entry struct {
+	Limit         int64             `json:"limit"`
+	PeriodSeconds int               `json:"period_seconds"`
+	Burst         int64             `json:"burst,omitempty"`
+	Algorithm     limiter.Algorithm `json:"algorithm,omitempty"`
+}
+
+type Registry struct {
+	Path     string
+	registry *memory.Registry
+}
+
+func NewRegistry(path string) (*Registry, error) {
+	registry := &Registry{
+		Path:     path,
+		registry: memory.NewRegistry(nil),
+	}
+
+	err := registry.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+func (registry *Registry) Get(ctx context.Context, token string) (limiter.Rate, bool, error) {
+	return registry.registry.Get(ctx, token)
+}
+
+// Reload reads the registry file again. The current rates are kept if the file is invalid.
+func (registry *Registry) Reload() error {
+	data, err := os.ReadFile(registry.Path)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read token registry %q", registry.Path)
+	}
+
+	entries := map[string]Entry{}
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse token registry %q", registry.Path)
+	}
+
+	rates := make(map[string]limiter.Rate, len(entries))
+	for token, entry := range entries {
+		if entry.Limit <= 0 || entry.PeriodSeconds <= 0 || !entry.Algorithm.IsValid() {
+			return errors.Errorf("invalid rate for token %q in token registry %q", token, registry.Path)
+		}
+
+		rates[token] = limiter.Rate{
+			Limit:     entry.Limit,
+			Period:    time.Duration(entry.PeriodSeconds) * time.Second,
+			Burst:     entry.Burst,
+			Algorithm: entry.Algorithm,
+		}
+	}
+
+	registry.registry.Replace(rates)
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/file"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileRegistry(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	err := os.WriteFile(path, []byte(`{
+		"free": {"limit": 10, "period_seconds": 60},
+		"premium": {"limit": 1000, "period_seconds": 60, "burst": 50, "algorithm": "gcra"}
+	}`), 0o600)
+	is.NoError(err)
+
+	registry, err := file.NewRegistry(path)
+	is.NoError(err)
+	is.NotNil(registry)
+
+	rate, ok, err := registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(limiter.Rate{Limit: 10, Period: 1 * time.Minute}, rate)
+
+	rate, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(limiter.Rate{
+		Limit:     1000,
+		Period:    1 * time.Minute,
+		Burst:     50,
+		Algorithm: limiter.GCRA,
+	}, rate)
+
+	_, ok, err = registry.Get(ctx, "unknown")
+	is.NoError(err)
+	is.False(ok)
+
+	// Check that an invalid file keeps the current rates.
+	for _, content := range []string{
+		`{"free": {"limit": 10, "period_seconds": 0}}`,
+		`{"free": {"limit": 0, "period_seconds": 60}}`,
+		`{"free": {"limit": 10, "period_seconds": 60, "algorithm": "leaky-bucket"}}`,
+	} {
+		err = os.WriteFile(path, []byte(content), 0o600)
+		is.NoError(err)
+
+		err = registry.Reload()
+		is.Error(err)
+	}
+
+	_, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.True(ok)
+
+	// Check that a valid file replaces the current rates.
+	err = os.WriteFile(path, []byte(`{"free": {"limit": 20, "period_seconds": 1}}`), 0o600)
+	is.NoError(err)
+
+	err = registry.Reload()
+	is.NoError(err)
+
+	rate, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(limiter.Rate{Limit: 20, Period: 1 * time.Second}, rate)
+
+	_, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.False(ok)
+
+	_, err = file.NewRegistry(filepath.Join(t.TempDir(), "missing.json"))
+	is.Error(err)
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+type Registry struct {
+	mutex sync.RWMutex
+	rates map[string]limiter.Rate
+}
+
+func NewRegistry(rates map[string]limiter.Rate) *Registry {
+	registry := &Registry{
+		rates: make(map[string]limiter.Rate, len(rates)),
+	}
+
+	for token, rate := range rates {
+		registry.rates[token] = rate
+	}
+
+	return registry
+}
+
+func (registry *Registry) Get(ctx context.Context, token string) (limiter.Rate, bool, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	rate, ok := registry.rates[token]
+	return rate, ok, nil
+}
+
+func (registry *Registry) Set(token string, rate limiter.Rate) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.rates[token] = rate
+}
+
+func (registry *Registry) Delete(token string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	delete(registry.rates, token)
+}
+
+// Replace swaps every rate of the registry at once.
+func (registry *Registry) Replace(rates map[string]limiter.Rate) {
+	replacement := make(map[string]limiter.Rate, len(rates))
+	for token, rate := range rates {
+		replacement[token] = rate
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.rates = replacement
+}
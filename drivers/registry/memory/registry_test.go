@@ -0,0 +1,58 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRegistry(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	free := limiter.Rate{Limit: 10, Period: 1 * time.Minute}
+	rates := map[string]limiter.Rate{"free": free}
+
+	registry := memory.NewRegistry(rates)
+
+	// Check that the registry does not share the given map.
+	delete(rates, "free")
+
+	rate, ok, err := registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(free, rate)
+
+	_, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.False(ok)
+
+	premium := limiter.Rate{Limit: 1000, Period: 1 * time.Minute, Burst: 50, Algorithm: limiter.GCRA}
+	registry.Set("premium", premium)
+
+	rate, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(premium, rate)
+
+	registry.Delete("free")
+
+	_, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.False(ok)
+
+	registry.Replace(map[string]limiter.Rate{"free": free})
+
+	_, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.False(ok)
+
+	rate, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(free, rate)
+}
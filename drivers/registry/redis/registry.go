@@ -0,0 +1,186 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// Client is the subset of the redis client used by the registry.
+type Client interface {
+	HGetAll(ctx context.Context, key string) *libredis.MapStringStringCmd
+	HSet(ctx context.Context, key string, values ...interface{}) *libredis.IntCmd
+	Del(ctx context.Context, keys ...string) *libredis.IntCmd
+}
+
+const (
+	// DefaultCacheTTL is how long the rate of a token, or its absence, is cached.
+	DefaultCacheTTL = 5 * time.Second
+	// maxCacheSize bounds the cache, which is cleared when it is full of unexpired tokens.
+	maxCacheSize = 100000
+)
+
+// Registry reads the rate of each token from a hash stored at "<prefix>:<token>", with the fields
+// "limit", "period" (in seconds) and optionally "burst" and "algorithm":
+//
+//	HSET limiter:tokens:abc123 limit 100 period 60
+//
+// Rates are cached for CacheTTL, so changes made by other instances apply after at most CacheTTL.
+type Registry struct {
+	Prefix   string
+	CacheTTL time.Duration
+	client   Client
+	mutex    sync.Mutex
+	cache    map[string]cachedRate
+}
+
+type cachedRate struct {
+	rate       limiter.Rate
+	ok         bool
+	expiration time.Time
+}
+
+func NewRegistry(client Client, prefix string) *Registry {
+	return &Registry{
+		Prefix:   prefix,
+		CacheTTL: DefaultCacheTTL,
+		client:   client,
+		cache:    make(map[string]cachedRate),
+	}
+}
+
+func (registry *Registry) Get(ctx context.Context, token string) (limiter.Rate, bool, error) {
+	now := time.Now()
+	if cached, ok := registry.getCached(token, now); ok {
+		return cached.rate, cached.ok, nil
+	}
+
+	rate, ok, err := registry.get(ctx, token)
+	if err != nil {
+		return limiter.Rate{}, false, err
+	}
+
+	registry.setCached(token, cachedRate{rate: rate, ok: ok, expiration: now.Add(registry.CacheTTL)})
+
+	return rate, ok, nil
+}
+
+func (registry *Registry) get(ctx context.Context, token string) (limiter.Rate, bool, error) {
+	fields, err := registry.client.HGetAll(ctx, registry.getKey(token)).Result()
+	if err != nil {
+		return limiter.Rate{}, false, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	if len(fields) == 0 {
+		return limiter.Rate{}, false, nil
+	}
+
+	rate, err := parseRate(fields)
+	if err != nil {
+		return limiter.Rate{}, false, errors.Wrapf(err, "invalid rate for token %q", token)
+	}
+
+	return rate, true, nil
+}
+
+func (registry *Registry) Set(ctx context.Context, token string, rate limiter.Rate) error {
+	registry.deleteCached(token)
+	return registry.client.HSet(ctx, registry.getKey(token),
+		"limit", rate.Limit,
+		"period", int64(rate.Period/time.Second),
+		"burst", rate.Burst,
+		"algorithm", string(rate.Algorithm),
+	).Err()
+}
+
+func (registry *Registry) Delete(ctx context.Context, token string) error {
+	registry.deleteCached(token)
+	return registry.client.Del(ctx, registry.getKey(token)).Err()
+}
+
+func (registry *Registry) getKey(token string) string {
+	buffer := strings.Builder{}
+	buffer.WriteString(registry.Prefix)
+	buffer.WriteString(":")
+	buffer.WriteString(token)
+	return buffer.String()
+}
+
+func (registry *Registry) getCached(token string, now time.Time) (cachedRate, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	cached, ok := registry.cache[token]
+	if !ok || !now.Before(cached.expiration) {
+		return cachedRate{}, false
+	}
+	return cached, true
+}
+
+func (registry *Registry) setCached(token string, cached cachedRate) {
+	if registry.CacheTTL <= 0 {
+		return
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if registry.cache == nil || len(registry.cache) >= maxCacheSize {
+		now := time.Now()
+		for key, value := range registry.cache {
+			if !now.Before(value.expiration) {
+				delete(registry.cache, key)
+			}
+		}
+		if registry.cache == nil || len(registry.cache) >= maxCacheSize {
+			registry.cache = make(map[string]cachedRate)
+		}
+	}
+
+	registry.cache[token] = cached
+}
+
+func (registry *Registry) deleteCached(token string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	delete(registry.cache, token)
+}
+
+func parseRate(fields map[string]string) (limiter.Rate, error) {
+	limit, err := strconv.ParseInt(fields["limit"], 10, 64)
+	if err != nil || limit <= 0 {
+		return limiter.Rate{}, errors.New(`field "limit" should be a positive number`)
+	}
+
+	period, err := strconv.ParseInt(fields["period"], 10, 64)
+	if err != nil || period <= 0 {
+		return limiter.Rate{}, errors.New(`field "period" should be a positive number of seconds`)
+	}
+
+	burst := int64(0)
+	if fields["burst"] != "" {
+		burst, err = strconv.ParseInt(fields["burst"], 10, 64)
+		if err != nil {
+			return limiter.Rate{}, errors.New(`field "burst" should be a number`)
+		}
+	}
+
+	algorithm := limiter.Algorithm(fields["algorithm"])
+	if !algorithm.IsValid() {
+		return limiter.Rate{}, errors.Errorf(`field "algorithm" has an unknown value %q`, algorithm)
+	}
+
+	return limiter.Rate{
+		Limit:     limit,
+		Period:    time.Duration(period) * time.Second,
+		Burst:     burst,
+		Algorithm: algorithm,
+	}, nil
+}
@@ -0,0 +1,148 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/registry/redis"
+	libredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	redisTestContainer "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+var redisContainer *redisTestContainer.RedisContainer
+var redisURL string
+
+func TestRedisRegistry(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	registry := redis.NewRegistry(client, "limiter:redis:registry-test")
+
+	_, ok, err := registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.False(ok)
+
+	premium := limiter.Rate{
+		Limit:     1000,
+		Period:    1 * time.Minute,
+		Burst:     50,
+		Algorithm: limiter.GCRA,
+	}
+
+	err = registry.Set(ctx, "premium", premium)
+	is.NoError(err)
+
+	rate, ok, err := registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(premium, rate)
+
+	err = client.HSet(ctx, "limiter:redis:registry-test:free", "limit", 10, "period", 60).Err()
+	is.NoError(err)
+
+	rate, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(limiter.Rate{Limit: 10, Period: 1 * time.Minute}, rate)
+
+	// Check that rates changed by another instance are cached until the cache expires.
+	registry.CacheTTL = 100 * time.Millisecond
+	other := redis.NewRegistry(client, "limiter:redis:registry-test")
+
+	err = other.Set(ctx, "free", limiter.Rate{Limit: 20, Period: 1 * time.Minute})
+	is.NoError(err)
+
+	rate, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(int64(10), rate.Limit)
+
+	time.Sleep(150 * time.Millisecond)
+
+	rate, ok, err = registry.Get(ctx, "free")
+	is.NoError(err)
+	is.True(ok)
+	is.Equal(int64(20), rate.Limit)
+
+	for token, fields := range map[string][]interface{}{
+		"invalid":   {"limit", "ten"},
+		"zero":      {"limit", 0, "period", 60},
+		"algorithm": {"limit", 10, "period", 60, "algorithm", "leaky-bucket"},
+	} {
+		err = client.HSet(ctx, "limiter:redis:registry-test:"+token, fields...).Err()
+		is.NoError(err)
+
+		_, _, err = registry.Get(ctx, token)
+		is.Error(err)
+	}
+
+	err = registry.Delete(ctx, "premium")
+	is.NoError(err)
+
+	_, ok, err = registry.Get(ctx, "premium")
+	is.NoError(err)
+	is.False(ok)
+}
+
+func newRedisClient(redisURL string) (*libredis.Client, error) {
+	url := fmt.Sprintf("%s/0", redisURL)
+
+	if os.Getenv("REDIS_URL") != "" {
+		url = os.Getenv("REDIS_URL")
+	}
+
+	opt, err := libredis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := libredis.NewClient(opt)
+	return client, nil
+}
+
+func runRedis(ctx context.Context, t testing.TB) *redisTestContainer.RedisContainer {
+	redisContainer, err := redisTestContainer.Run(
+		ctx,
+		"redis:7.2.4-alpine3.19",
+		redisTestContainer.WithSnapshotting(10, 1),
+		redisTestContainer.WithLogLevel(redisTestContainer.LogLevelVerbose),
+		testcontainers.WithHostPortAccess(6379),
+	)
+	if err != nil {
+		t.Fatalf("failed to start container: %s", err)
+	}
+
+	return redisContainer
+}
+
+func setup(ctx context.Context, t testing.TB) {
+	redisContainer = runRedis(ctx, t)
+
+	connectionString, err := redisContainer.ConnectionString(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	redisURL = connectionString
+}
+
+func tearDown(t testing.TB) {
+	if err := testcontainers.TerminateContainer(redisContainer); err != nil {
+		t.Fatalf("failed to terminate container: %s", err)
+	}
+}
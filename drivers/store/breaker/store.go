@@ -0,0 +1,256 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+const (
+	DefaultMaxFailures      = 5
+	DefaultOpenTimeout      = 5 * time.Second
+	DefaultHalfOpenRequests = 1
+)
+
+// State is the state of the circuit breaker.
+type State int
+
+const (
+	// StateClosed calls the store.
+	StateClosed State = iota
+	// StateOpen fails without calling the store, until the open timeout elapses.
+	StateOpen
+	// StateHalfOpen lets a few probe calls reach the store, to decide whether it recovered.
+	StateHalfOpen
+)
+
+func (state State) String() string {
+	switch state {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+type Options struct {
+	// MaxFailures is the number of consecutive failures opening the circuit.
+	MaxFailures int
+	// MaxLatency counts calls slower than it as failures, when set.
+	MaxLatency time.Duration
+	// OpenTimeout is the duration the circuit stays open before probing the store.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of successful probes closing the circuit.
+	HalfOpenRequests int
+}
+
+// Store wraps a store with a circuit breaker: while the circuit is open, calls fail fast with
+// limiter.ErrStoreUnavailable.
+type Store struct {
+	Options
+	store     limiter.Store
+	mutex     sync.Mutex
+	state     State
+	failures  int
+	probes    int
+	successes int
+	openedAt  time.Time
+}
+
+func NewStore(store limiter.Store) *Store {
+	return NewStoreWithOptions(store, Options{
+		MaxFailures:      DefaultMaxFailures,
+		OpenTimeout:      DefaultOpenTimeout,
+		HalfOpenRequests: DefaultHalfOpenRequests,
+	})
+}
+
+func NewStoreWithOptions(store limiter.Store, options Options) *Store {
+	if options.MaxFailures <= 0 {
+		options.MaxFailures = DefaultMaxFailures
+	}
+	if options.OpenTimeout <= 0 {
+		options.OpenTimeout = DefaultOpenTimeout
+	}
+	if options.HalfOpenRequests <= 0 {
+		options.HalfOpenRequests = DefaultHalfOpenRequests
+	}
+
+	return &Store{
+		Options: options,
+		store:   store,
+	}
+}
+
+func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.call(ctx, func() (limiter.Context, error) {
+		return store.store.Get(ctx, key, rate)
+	})
+}
+
+func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.call(ctx, func() (limiter.Context, error) {
+		return store.store.Peek(ctx, key, rate)
+	})
+}
+
+func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.call(ctx, func() (limiter.Context, error) {
+		return store.store.Reset(ctx, key, rate)
+	})
+}
+
+func (store *Store) Inc(ctx context.Context, key string, count int64, rate limiter.Rate) (limiter.Context, error) {
+	return store.call(ctx, func() (limiter.Context, error) {
+		return store.store.Inc(ctx, key, count, rate)
+	})
+}
+
+func (store *Store) IncMulti(ctx context.Context, key string, count int64,
+	rates []limiter.Rate) (limiter.Context, error) {
+
+	multi, ok := store.store.(limiter.MultiStore)
+	if !ok {
+		return limiter.Context{}, limiter.ErrMultipleRatesUnsupported
+	}
+	return store.call(ctx, func() (limiter.Context, error) {
+		return multi.IncMulti(ctx, key, count, rates)
+	})
+}
+
+func (store *Store) PeekMulti(ctx context.Context, key string, rates []limiter.Rate) (limiter.Context, error) {
+	multi, ok := store.store.(limiter.MultiStore)
+	if !ok {
+		return limiter.Context{}, limiter.ErrMultipleRatesUnsupported
+	}
+	return store.call(ctx, func() (limiter.Context, error) {
+		return multi.PeekMulti(ctx, key, rates)
+	})
+}
+
+func (store *Store) Scan(ctx context.Context, cursor uint64, count int64) ([]limiter.KeyState, uint64, error) {
+	scan, ok := store.store.(limiter.ScanStore)
+	if !ok {
+		return nil, 0, limiter.ErrScanUnsupported
+	}
+
+	var next uint64
+	var states []limiter.KeyState
+	_, err := store.call(ctx, func() (limiter.Context, error) {
+		var err error
+		states, next, err = scan.Scan(ctx, cursor, count)
+		return limiter.Context{}, err
+	})
+	return states, next, err
+}
+
+// State returns the current state of the circuit.
+func (store *Store) State() State {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	return store.state
+}
+
+func (store *Store) call(ctx context.Context, fn func() (limiter.Context, error)) (limiter.Context, error) {
+	if !store.allow() {
+		return limiter.Context{}, limiter.ErrStoreUnavailable
+	}
+
+	done := ctx.Err() != nil
+	start := time.Now()
+	lctx, err := fn()
+	if err != nil && !isStoreFailure(err, done) {
+		store.release()
+		return lctx, err
+	}
+	store.record(err == nil && (store.MaxLatency <= 0 || time.Since(start) <= store.MaxLatency))
+
+	return lctx, err
+}
+
+// isStoreFailure reports whether the error of a call is a failure of the store. Cancellations by the
+// caller, deadlines which had expired before the call and invalid rates say nothing about the store.
+func isStoreFailure(err error, done bool) bool {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case done && errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.Is(err, limiter.ErrInvalidRate), errors.Is(err, limiter.ErrMultipleRatesUnsupported),
+		errors.Is(err, limiter.ErrScanUnsupported):
+		return false
+	}
+	return true
+}
+
+// allow returns whether a call may reach the store, moving an open circuit to half-open once the
+// open timeout elapsed.
+func (store *Store) allow() bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	switch store.state {
+	case StateOpen:
+		if time.Since(store.openedAt) < store.OpenTimeout {
+			return false
+		}
+		store.state = StateHalfOpen
+		store.probes = 0
+		store.successes = 0
+		fallthrough
+	case StateHalfOpen:
+		if store.probes >= store.HalfOpenRequests {
+			return false
+		}
+		store.probes++
+	}
+
+	return true
+}
+
+// release gives back the probe of a call whose outcome is not recorded.
+func (store *Store) release() {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.state == StateHalfOpen && store.probes > 0 {
+		store.probes--
+	}
+}
+
+func (store *Store) record(success bool) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	switch store.state {
+	case StateClosed:
+		if success {
+			store.failures = 0
+			return
+		}
+		store.failures++
+		if store.failures >= store.MaxFailures {
+			store.open()
+		}
+	case StateHalfOpen:
+		if !success {
+			store.open()
+			return
+		}
+		store.successes++
+		if store.successes >= store.HalfOpenRequests {
+			store.state = StateClosed
+			store.failures = 0
+		}
+	}
+}
+
+func (store *Store) open() {
+	store.state = StateOpen
+	store.openedAt = time.Now()
+}
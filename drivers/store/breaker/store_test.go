@@ -0,0 +1,139 @@
+package breaker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/breaker"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
+)
+
+type flakyStore struct {
+	limiter.Store
+	failing int32
+	delay   int64
+	calls   int64
+}
+
+func (store *flakyStore) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	atomic.AddInt64(&store.calls, 1)
+	time.Sleep(time.Duration(atomic.LoadInt64(&store.delay)))
+	if atomic.LoadInt32(&store.failing) == 1 {
+		return limiter.Context{}, errors.New("connection refused")
+	}
+	if err := ctx.Err(); err != nil {
+		return limiter.Context{}, errors.Wrap(err, "an error has occurred with redis command")
+	}
+	return store.Store.Get(ctx, key, rate)
+}
+
+func TestBreakerStore(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+	rate := limiter.Rate{Limit: 100, Period: 1 * time.Minute}
+
+	flaky := &flakyStore{Store: memory.NewStore(), failing: 1}
+	store := breaker.NewStoreWithOptions(flaky, breaker.Options{
+		MaxFailures: 3,
+		OpenTimeout: 50 * time.Millisecond,
+	})
+
+	for i := 1; i <= 3; i++ {
+		_, err := store.Get(ctx, "foo", rate)
+		is.Error(err)
+		is.NotErrorIs(err, limiter.ErrStoreUnavailable)
+	}
+	is.Equal(breaker.StateOpen, store.State())
+
+	_, err := store.Get(ctx, "foo", rate)
+	is.ErrorIs(err, limiter.ErrStoreUnavailable)
+	is.Equal(int64(3), atomic.LoadInt64(&flaky.calls))
+
+	// A failed probe opens the circuit again.
+	time.Sleep(60 * time.Millisecond)
+	_, err = store.Get(ctx, "foo", rate)
+	is.Error(err)
+	is.NotErrorIs(err, limiter.ErrStoreUnavailable)
+	is.Equal(breaker.StateOpen, store.State())
+
+	time.Sleep(60 * time.Millisecond)
+	atomic.StoreInt32(&flaky.failing, 0)
+	lctx, err := store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(99), lctx.Remaining)
+	is.Equal(breaker.StateClosed, store.State())
+}
+
+func TestBreakerStoreWithMaxLatency(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+	rate := limiter.Rate{Limit: 100, Period: 1 * time.Minute}
+
+	flaky := &flakyStore{Store: memory.NewStore(), delay: int64(20 * time.Millisecond)}
+	store := breaker.NewStoreWithOptions(flaky, breaker.Options{
+		MaxFailures: 2,
+		MaxLatency:  10 * time.Millisecond,
+		OpenTimeout: 1 * time.Minute,
+	})
+
+	for i := 1; i <= 2; i++ {
+		_, err := store.Get(ctx, "foo", rate)
+		is.NoError(err)
+	}
+	is.Equal(breaker.StateOpen, store.State())
+
+	_, err := store.Get(ctx, "foo", rate)
+	is.ErrorIs(err, limiter.ErrStoreUnavailable)
+}
+
+func TestBreakerStoreWithCallerErrors(t *testing.T) {
+	is := require.New(t)
+	rate := limiter.Rate{Limit: 100, Period: 1 * time.Minute}
+
+	flaky := &flakyStore{Store: memory.NewStoreWithOptions(limiter.StoreOptions{MaxLogSize: 10})}
+	store := breaker.NewStoreWithOptions(flaky, breaker.Options{
+		MaxFailures: 2,
+		OpenTimeout: 1 * time.Minute,
+	})
+
+	// Clients aborting their requests don't open the circuit.
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		_, err := store.Get(canceled, "foo", rate)
+		is.ErrorIs(err, context.Canceled)
+
+		_, err = store.Get(expired, "foo", rate)
+		is.ErrorIs(err, context.DeadlineExceeded)
+	}
+	is.Equal(breaker.StateClosed, store.State())
+
+	// Neither do rates the store can't apply.
+	invalid := limiter.Rate{Limit: 100, Period: 1 * time.Minute, Algorithm: limiter.SlidingLog}
+	for i := 0; i < 5; i++ {
+		_, err := store.Get(context.Background(), "foo", invalid)
+		is.ErrorIs(err, limiter.ErrInvalidRate)
+
+		_, err = store.IncMulti(context.Background(), "foo", 1, []limiter.Rate{rate, rate})
+		is.ErrorIs(err, limiter.ErrMultipleRatesUnsupported)
+	}
+	is.Equal(breaker.StateClosed, store.State())
+
+	// Store failures still open it.
+	atomic.StoreInt32(&flaky.failing, 1)
+	for i := 0; i < 2; i++ {
+		_, err := store.Get(context.Background(), "foo", rate)
+		is.Error(err)
+	}
+	is.Equal(breaker.StateOpen, store.State())
+}
@@ -30,3 +30,31 @@ func GetContextFromState(
 		Reached:   reached,
 	}
 }
+
+// GetBlockedContextFromState builds the context of a key rejected until given instant.
+func GetBlockedContextFromState(limit int64, blockedUntil time.Time) limiter.Context {
+	return limiter.Context{
+		Limit:        limit,
+		Remaining:    0,
+		Reset:        blockedUntil.Unix(),
+		Reached:      true,
+		BlockedUntil: blockedUntil.Unix(),
+	}
+}
+
+// GetLimit returns the number of requests reported as the limit of given rate.
+func GetLimit(rate limiter.Rate) int64 {
+	if rate.Algorithm == limiter.GCRA {
+		_, _, burst := GetGCRAParams(rate)
+		return burst
+	}
+	return rate.Limit
+}
+
+// GetWindowTTLMilliseconds returns the TTL of a fixed window starting at given instant, rounded up to
+// a whole millisecond: a calendar window ending within the millisecond still expires, instead of
+// being kept forever or deleted at once by a TTL of 0.
+func GetWindowTTLMilliseconds(rate limiter.Rate, now time.Time) int64 {
+	ttl := rate.GetWindowTTL(now)
+	return max(1, int64((ttl+time.Millisecond-1)/time.Millisecond))
+}
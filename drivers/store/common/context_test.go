@@ -0,0 +1,24 @@
+package common_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+func TestGetWindowTTLMilliseconds(t *testing.T) {
+	is := require.New(t)
+
+	rate := limiter.NewCalendarRate(10, limiter.CalendarDay, time.UTC)
+	boundary := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	is.Equal(int64(1), common.GetWindowTTLMilliseconds(rate, boundary.Add(-time.Microsecond)))
+	is.Equal(int64(1), common.GetWindowTTLMilliseconds(rate, boundary.Add(-time.Millisecond)))
+	is.Equal(int64(2), common.GetWindowTTLMilliseconds(rate, boundary.Add(-1500*time.Microsecond)))
+	is.Equal(int64(24*time.Hour/time.Millisecond), common.GetWindowTTLMilliseconds(rate, boundary))
+	is.Equal(int64(60000), common.GetWindowTTLMilliseconds(limiter.NewRate(10, 60), boundary))
+}
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// GetGCRAParams returns the emission interval between two requests, the tolerance allowing bursts and
+// the burst size of given rate. The burst defaults to the rate limit.
+func GetGCRAParams(rate limiter.Rate) (time.Duration, time.Duration, int64) {
+	burst := rate.Burst
+	if burst <= 0 {
+		burst = rate.Limit
+	}
+
+	limit := rate.Limit
+	if limit <= 0 {
+		limit = 1
+	}
+
+	interval := rate.Period / time.Duration(limit)
+	if interval <= 0 {
+		interval = 1
+	}
+
+	return interval, interval * time.Duration(burst), burst
+}
+
+// GetGCRAContextFromState builds the context of a GCRA bucket from its theoretical arrival time.
+// Reset is the instant at which the next token is available, or at which count tokens are available
+// if the request was rejected.
+func GetGCRAContextFromState(
+	now time.Time,
+	rate limiter.Rate,
+	tat time.Time,
+	count int64,
+	reached bool,
+) limiter.Context {
+	interval, tolerance, burst := GetGCRAParams(rate)
+
+	debt := tat.Sub(now)
+	if debt < 0 {
+		debt = 0
+	}
+
+	remaining := int64((tolerance - debt) / interval)
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining > burst {
+		remaining = burst
+	}
+
+	needed := remaining + 1
+	if reached && count > 0 {
+		needed = count
+	}
+
+	reset := now
+	if needed <= burst || reached {
+		if wait := time.Duration(needed)*interval - (tolerance - debt); wait > 0 {
+			reset = now.Add(wait)
+		}
+	}
+
+	return limiter.Context{
+		Limit:     burst,
+		Remaining: remaining,
+		Reset:     reset.Add(time.Second - 1).Unix(),
+		Reached:   reached,
+	}
+}
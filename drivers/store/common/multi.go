@@ -0,0 +1,69 @@
+package common
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// CheckMultiRates verifies that given rates can be applied together: they must use the fixed window
+// algorithm, without block, and have distinct periods.
+func CheckMultiRates(rates []limiter.Rate) error {
+	periods := make(map[time.Duration]bool, len(rates))
+	for _, rate := range rates {
+		if rate.Algorithm != "" && rate.Algorithm != limiter.FixedWindow {
+			return errors.Wrapf(limiter.ErrInvalidRate, "algorithm %q is not supported with multiple rates", rate.Algorithm)
+		}
+		if rate.Block > 0 {
+			return errors.Wrap(limiter.ErrInvalidRate, "block duration is not supported with multiple rates")
+		}
+		if periods[rate.Period] {
+			return errors.Wrapf(limiter.ErrInvalidRate, "several rates have the period %s", rate.Period)
+		}
+		periods[rate.Period] = true
+	}
+	return nil
+}
+
+// GetMultiContextFromState builds the context of the most restrictive of given rates, from the
+// counter and the expiration of each one. If the increment was rejected, it is the context of the
+// rejecting rate resetting last. Otherwise it is the one with the least remaining quota.
+// A zero count only reads the state, and reports as reached the rates which would reject the next
+// request.
+func GetMultiContextFromState(
+	now time.Time,
+	rates []limiter.Rate,
+	counts []int64,
+	expirations []time.Time,
+	count int64,
+	ok bool,
+) limiter.Context {
+	var result limiter.Context
+	for i, rate := range rates {
+		lctx := GetContextFromState(now, rate, expirations[i], counts[i])
+		lctx.RateIndex = i
+		switch {
+		case count == 0:
+			lctx.Reached = counts[i] >= rate.Limit
+		case !ok:
+			lctx.Reached = counts[i]+count > rate.Limit
+		}
+
+		if i == 0 || isMoreRestrictive(lctx, result) {
+			result = lctx
+		}
+	}
+	return result
+}
+
+func isMoreRestrictive(lctx limiter.Context, than limiter.Context) bool {
+	if lctx.Reached != than.Reached {
+		return lctx.Reached
+	}
+	if !lctx.Reached && lctx.Remaining != than.Remaining {
+		return lctx.Remaining < than.Remaining
+	}
+	return lctx.Reset > than.Reset
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// CheckSlidingLogRate verifies that every request allowed by given rate can be recorded in a log
+// holding at most maxSize timestamps.
+func CheckSlidingLogRate(rate limiter.Rate, maxSize int64) error {
+	if rate.Limit > maxSize {
+		return errors.Wrapf(limiter.ErrInvalidRate, "sliding log limit %d exceeds the maximum log size %d",
+			rate.Limit, maxSize)
+	}
+	return nil
+}
+
+// GetSlidingLogContextFromState builds the context of a sliding log holding total timestamps.
+// Reset is the instant at which the oldest timestamp leaves the sliding period.
+func GetSlidingLogContextFromState(
+	now time.Time,
+	rate limiter.Rate,
+	oldest time.Time,
+	total int64,
+	reached bool,
+) limiter.Context {
+	limit := rate.Limit
+
+	remaining := limit - total
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	reset := now.Add(rate.Period)
+	if total > 0 {
+		reset = oldest.Add(rate.Period)
+	}
+
+	return limiter.Context{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset.Unix(),
+		Reached:   reached,
+	}
+}
@@ -0,0 +1,95 @@
+package common
+
+import (
+	"math"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// GetSlidingWindowCount returns the estimated number of requests in the sliding period ending at now:
+// the current window counter plus the previous window counter weighted by its remaining overlap.
+// The weighted part is rounded up, so the estimate never undercounts. A window ahead of now, started
+// by an instance whose clock is ahead, is weighted as if it had just started.
+func GetSlidingWindowCount(
+	now time.Time,
+	rate limiter.Rate,
+	window time.Time,
+	previous int64,
+	current int64,
+) int64 {
+	if now.Before(window) {
+		now = window
+	}
+
+	period := float64(rate.Period)
+	overlap := period - float64(now.Sub(window))
+	if previous <= 0 || overlap <= 0 {
+		return current
+	}
+
+	return current + int64(math.Ceil(float64(previous)*overlap/period))
+}
+
+// GetSlidingWindowContextFromState builds the context of a sliding window counter.
+// Reset is the end of the current window while there is remaining quota, otherwise it is the instant
+// at which the weighted previous window has decayed enough to allow a new request.
+func GetSlidingWindowContextFromState(
+	now time.Time,
+	rate limiter.Rate,
+	window time.Time,
+	previous int64,
+	current int64,
+	reached bool,
+) limiter.Context {
+	limit := rate.Limit
+	count := GetSlidingWindowCount(now, rate, window, previous, current)
+
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	reset := window.Add(rate.Period)
+	if reached || remaining == 0 {
+		reset = getSlidingWindowNextAllowed(now, rate, window, previous, current)
+	}
+
+	return limiter.Context{
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     reset.Unix(),
+		Reached:   reached,
+	}
+}
+
+func getSlidingWindowNextAllowed(
+	now time.Time,
+	rate limiter.Rate,
+	window time.Time,
+	previous int64,
+	current int64,
+) time.Time {
+	period := float64(rate.Period)
+
+	// Within the current window, only the previous counter decays.
+	available := float64(rate.Limit - 1 - current)
+	if available >= 0 && previous > 0 {
+		offset := period - available*period/float64(previous)
+		next := window.Add(time.Duration(math.Ceil(offset)))
+		if next.Before(now) {
+			return now
+		}
+		return next
+	}
+
+	// Otherwise the current counter becomes the previous one of the next window.
+	available = float64(rate.Limit - 1)
+	if available < 0 || current <= 0 {
+		return window.Add(2 * rate.Period)
+	}
+
+	offset := math.Max(period-available*period/float64(current), 0)
+
+	return window.Add(rate.Period + time.Duration(math.Ceil(offset)))
+}
@@ -0,0 +1,295 @@
+package hybrid
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+const DefaultSyncInterval = 10 * time.Millisecond
+
+type entry struct {
+	rate limiter.Rate
+	// context is the state of the key returned by the remote store on the last sync.
+	context limiter.Context
+	// pending is the number of increments applied locally and not yet synced.
+	pending int64
+}
+
+// exceeds returns whether count more increments would exceed the limit, as known locally.
+func (e *entry) exceeds(count int64) bool {
+	return e.context.Reached || e.context.BlockedUntil > 0 || e.context.Remaining-e.pending-count < 0
+}
+
+func (e *entry) getContext() limiter.Context {
+	lctx := e.context
+	lctx.Remaining -= e.pending
+	if lctx.Remaining < 0 {
+		lctx.Remaining = 0
+	}
+	return lctx
+}
+
+func (e *entry) getReachedContext() limiter.Context {
+	lctx := e.getContext()
+	lctx.Remaining = 0
+	lctx.Reached = true
+	return lctx
+}
+
+// Store is a store keeping an approximate copy of each key locally, in front of a remote store.
+// Requests are answered from the local copy, and their increments are synced to the remote store
+// in batches. Keys are only read synchronously from the remote store when they are not known
+// locally, so the limit may be exceeded by the increments of one sync interval of each instance.
+type Store struct {
+	*syncer
+}
+
+type syncer struct {
+	remote   limiter.Store
+	interval time.Duration
+	mutex    sync.Mutex
+	entries  map[string]*entry
+	stop     chan struct{}
+}
+
+func NewStore(remote limiter.Store) *Store {
+	return NewStoreWithInterval(remote, DefaultSyncInterval)
+}
+
+// NewStoreWithInterval creates a store syncing increments to the remote store every interval.
+func NewStoreWithInterval(remote limiter.Store, interval time.Duration) *Store {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+
+	syncer := &syncer{
+		remote:   remote,
+		interval: interval,
+		entries:  make(map[string]*entry),
+		stop:     make(chan struct{}),
+	}
+
+	// The wrapper ensures the sync goroutine does not prevent the store from being garbage collected.
+	store := &Store{syncer: syncer}
+	go syncer.run()
+	runtime.SetFinalizer(store, stopSyncer)
+
+	return store
+}
+
+func stopSyncer(store *Store) {
+	close(store.stop)
+}
+
+func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.Inc(ctx, key, 1, rate)
+}
+
+func (store *Store) Inc(ctx context.Context, key string, count int64, rate limiter.Rate) (limiter.Context, error) {
+	if count < 0 {
+		// Grants are rare: apply them right away and read the key again on the next call.
+		store.forget(key)
+		return store.remote.Inc(ctx, key, count, rate)
+	}
+
+	store.mutex.Lock()
+	item, ok := store.entries[key]
+	if ok && item.rate == rate && !store.expired(item) {
+		defer store.mutex.Unlock()
+
+		// Like the stores, the fixed window counts rejected requests, except weighted ones, while the
+		// other algorithms only count the accepted ones.
+		countsRejected := isFixedWindow(rate) && count <= 1
+		if !countsRejected && item.exceeds(count) {
+			return item.getReachedContext(), nil
+		}
+
+		item.pending += count
+		if countsRejected && item.exceeds(0) {
+			return item.getReachedContext(), nil
+		}
+		return item.getContext(), nil
+	}
+	store.mutex.Unlock()
+
+	lctx, err := store.remote.Inc(ctx, key, count, rate)
+	if err != nil {
+		return lctx, err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	// The increments pending in an expired window are dropped, as the remote window they belonged to.
+	item, ok = store.entries[key]
+	if !ok || item.rate != rate || store.expired(item) {
+		item = &entry{rate: rate}
+		store.entries[key] = item
+	}
+	item.context = lctx
+
+	return item.getContext(), nil
+}
+
+func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	store.mutex.Lock()
+	item, ok := store.entries[key]
+	if ok && item.rate == rate && !store.expired(item) {
+		defer store.mutex.Unlock()
+		// Like the stores, the fixed window reports a key as reached once its limit is exceeded,
+		// and the other algorithms once the next request would be rejected.
+		next := int64(1)
+		if isFixedWindow(rate) {
+			next = 0
+		}
+		if item.exceeds(next) {
+			return item.getReachedContext(), nil
+		}
+		return item.getContext(), nil
+	}
+	store.mutex.Unlock()
+
+	return store.remote.Peek(ctx, key, rate)
+}
+
+func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	store.forget(key)
+	return store.remote.Reset(ctx, key, rate)
+}
+
+// IncMulti applies several rates to the key in the remote store. Keeping the rates consistent requires
+// checking them together, so they are not cached locally.
+func (store *Store) IncMulti(ctx context.Context, key string, count int64,
+	rates []limiter.Rate) (limiter.Context, error) {
+
+	multi, ok := store.remote.(limiter.MultiStore)
+	if !ok {
+		return limiter.Context{}, limiter.ErrMultipleRatesUnsupported
+	}
+	return multi.IncMulti(ctx, key, count, rates)
+}
+
+func (store *Store) PeekMulti(ctx context.Context, key string, rates []limiter.Rate) (limiter.Context, error) {
+	multi, ok := store.remote.(limiter.MultiStore)
+	if !ok {
+		return limiter.Context{}, limiter.ErrMultipleRatesUnsupported
+	}
+	return multi.PeekMulti(ctx, key, rates)
+}
+
+// Scan lists the keys of the remote store, without the increments which are not synced yet.
+func (store *Store) Scan(ctx context.Context, cursor uint64, count int64) ([]limiter.KeyState, uint64, error) {
+	scan, ok := store.remote.(limiter.ScanStore)
+	if !ok {
+		return nil, 0, limiter.ErrScanUnsupported
+	}
+	return scan.Scan(ctx, cursor, count)
+}
+
+// Sync sends the pending increments to the remote store, and refreshes the local copy of the keys.
+func (store *Store) Sync(ctx context.Context) error {
+	return store.sync(ctx)
+}
+
+func (syncer *syncer) run() {
+	ticker := time.NewTicker(syncer.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = syncer.sync(context.Background())
+		case <-syncer.stop:
+			return
+		}
+	}
+}
+
+type batch struct {
+	key     string
+	item    *entry
+	rate    limiter.Rate
+	pending int64
+}
+
+// sync sends the pending increments of each key to the remote store, refreshing their local copy.
+// Keys without pending increments, or whose window expired, are dropped so that they are read again
+// from the remote store: the increments pending in an expired window are not carried into the next one.
+func (syncer *syncer) sync(ctx context.Context) error {
+	syncer.mutex.Lock()
+	batches := make([]batch, 0, len(syncer.entries))
+	for key, item := range syncer.entries {
+		if item.pending > 0 && !syncer.expired(item) {
+			batches = append(batches, batch{key: key, item: item, rate: item.rate, pending: item.pending})
+		} else {
+			delete(syncer.entries, key)
+		}
+	}
+	syncer.mutex.Unlock()
+
+	var err error
+	for _, batch := range batches {
+		lctx, incErr := syncer.inc(ctx, batch)
+		if incErr != nil {
+			// The increments stay pending and are sent again on the next sync.
+			err = incErr
+			continue
+		}
+
+		syncer.mutex.Lock()
+		if syncer.entries[batch.key] == batch.item {
+			batch.item.context = lctx
+			// Except with the fixed window, the remote store rejects a batch exceeding the limit as a
+			// whole: its increments stay pending until the remote store accepts them.
+			if isFixedWindow(batch.rate) || (!lctx.Reached && lctx.BlockedUntil == 0) {
+				batch.item.pending -= batch.pending
+			}
+		}
+		syncer.mutex.Unlock()
+	}
+
+	return err
+}
+
+// inc sends the increments of the batch to the remote store. The remote store does not count a
+// weighted increment exceeding the limit, while the fixed window counted the requests of the batch
+// one by one: the ones fitting in the remaining quota are sent, then a single one exceeding it. If the
+// remote store fails in between, the batch is not sent again, as part of it is counted.
+func (syncer *syncer) inc(ctx context.Context, batch batch) (limiter.Context, error) {
+	lctx, err := syncer.remote.Inc(ctx, batch.key, batch.pending, batch.rate)
+	if err != nil || !isFixedWindow(batch.rate) || batch.pending <= 1 || !lctx.Reached || lctx.BlockedUntil > 0 {
+		return lctx, err
+	}
+
+	if lctx.Remaining > 0 {
+		_, err = syncer.remote.Inc(ctx, batch.key, lctx.Remaining, batch.rate)
+		if err != nil {
+			return lctx, nil
+		}
+	}
+
+	exceeded, err := syncer.remote.Inc(ctx, batch.key, 1, batch.rate)
+	if err != nil {
+		return lctx, nil
+	}
+	return exceeded, nil
+}
+
+func (syncer *syncer) forget(key string) {
+	syncer.mutex.Lock()
+	defer syncer.mutex.Unlock()
+	delete(syncer.entries, key)
+}
+
+// expired returns whether the remote state of the entry reset since the last sync.
+func (syncer *syncer) expired(item *entry) bool {
+	return item.context.Reset > 0 && time.Now().Unix() >= item.context.Reset
+}
+
+func isFixedWindow(rate limiter.Rate) bool {
+	return rate.Algorithm == "" || rate.Algorithm == limiter.FixedWindow
+}
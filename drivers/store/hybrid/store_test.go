@@ -0,0 +1,162 @@
+package hybrid_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/hybrid"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/tests"
+)
+
+type countingStore struct {
+	limiter.Store
+	calls int64
+}
+
+func (store *countingStore) Inc(ctx context.Context, key string, count int64, rate limiter.Rate) (limiter.Context, error) {
+	atomic.AddInt64(&store.calls, 1)
+	return store.Store.Inc(ctx, key, count, rate)
+}
+
+func TestHybridStoreSequentialAccess(t *testing.T) {
+	tests.TestStoreSequentialAccess(t, hybrid.NewStore(memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:hybrid:sequential-test",
+		CleanUpInterval: 30 * time.Second,
+	})))
+}
+
+func TestHybridStoreConcurrentAccess(t *testing.T) {
+	tests.TestStoreConcurrentAccess(t, hybrid.NewStore(memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:hybrid:concurrent-test",
+		CleanUpInterval: 30 * time.Second,
+	})))
+}
+
+func TestHybridStoreBatchedSync(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+	rate := limiter.Rate{Limit: 10, Period: 1 * time.Minute}
+
+	remote := &countingStore{Store: memory.NewStore()}
+	store := hybrid.NewStoreWithInterval(remote, 1*time.Hour)
+
+	for i := int64(1); i <= 15; i++ {
+		lctx, err := store.Get(ctx, "foo", rate)
+		is.NoError(err)
+
+		if i <= 10 {
+			is.Equal(10-i, lctx.Remaining)
+			is.False(lctx.Reached)
+		} else {
+			is.Equal(int64(0), lctx.Remaining)
+			is.True(lctx.Reached)
+		}
+	}
+
+	// Only the first request reached the remote store, the others are pending.
+	is.Equal(int64(1), atomic.LoadInt64(&remote.calls))
+
+	// The batch exceeds the limit: the remote store rejects it, so the increments fitting in the limit
+	// are sent, then a single one exceeding it.
+	is.NoError(store.Sync(ctx))
+	is.Equal(int64(4), atomic.LoadInt64(&remote.calls))
+
+	lctx, err := remote.Peek(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(0), lctx.Remaining)
+	is.True(lctx.Reached)
+
+	// Increments from other instances are seen once the key is read again.
+	lctx, err = store.Get(ctx, "bar", rate)
+	is.NoError(err)
+	is.Equal(int64(9), lctx.Remaining)
+
+	_, err = remote.Inc(ctx, "bar", 5, rate)
+	is.NoError(err)
+	is.NoError(store.Sync(ctx))
+
+	lctx, err = store.Get(ctx, "bar", rate)
+	is.NoError(err)
+	is.Equal(int64(3), lctx.Remaining)
+}
+
+func TestHybridStoreRejectedSync(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+	rate := limiter.Rate{Limit: 10, Period: 1 * time.Minute, Algorithm: limiter.SlidingLog}
+
+	remote := memory.NewStore()
+	store := hybrid.NewStoreWithInterval(remote, 1*time.Hour)
+
+	for i := 0; i < 6; i++ {
+		_, err := store.Get(ctx, "foo", rate)
+		is.NoError(err)
+	}
+
+	// Another instance consumed most of the limit, so the remote store rejects the batch.
+	_, err := remote.Inc(ctx, "foo", 8, rate)
+	is.NoError(err)
+	is.NoError(store.Sync(ctx))
+
+	lctx, err := remote.Peek(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(1), lctx.Remaining)
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.True(lctx.Reached)
+
+	// The rejected increments are sent again once the remote store accepts them.
+	_, err = remote.Reset(ctx, "foo", rate)
+	is.NoError(err)
+	is.NoError(store.Sync(ctx))
+
+	lctx, err = remote.Peek(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(5), lctx.Remaining)
+}
+
+func TestHybridStoreExpiredSync(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+	rate := limiter.Rate{Limit: 10, Period: 1 * time.Second}
+
+	remote := memory.NewStore()
+	store := hybrid.NewStoreWithInterval(remote, 1*time.Hour)
+
+	var lctx limiter.Context
+	var err error
+	for i := 0; i < 4; i++ {
+		lctx, err = store.Get(ctx, "foo", rate)
+		is.NoError(err)
+	}
+	is.Equal(int64(6), lctx.Remaining)
+
+	// Reset is truncated to the second: the window is over one second later.
+	time.Sleep(time.Until(time.Unix(lctx.Reset+1, 0)))
+
+	// The increments pending in the expired window are not carried into the next one.
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(9), lctx.Remaining)
+
+	for i := 0; i < 2; i++ {
+		lctx, err = store.Get(ctx, "bar", rate)
+		is.NoError(err)
+	}
+
+	// Reset is truncated to the second: the window is over one second later.
+	time.Sleep(time.Until(time.Unix(lctx.Reset+1, 0)))
+
+	is.NoError(store.Sync(ctx))
+
+	lctx, err = remote.Peek(ctx, "bar", rate)
+	is.NoError(err)
+	is.Equal(int64(10), lctx.Remaining)
+}
@@ -0,0 +1,542 @@
+package memory
+
+import (
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+const shardCount = 64
+
+type entry struct {
+	count      int64
+	previous   int64
+	window     time.Time
+	log        []time.Time
+	expiration time.Time
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !now.Before(e.expiration)
+}
+
+// slide moves the entry to given window, turning the current counter into the previous one
+// when the windows are adjacent.
+func (e *entry) slide(window time.Time, period time.Duration) {
+	switch {
+	case e.window.Equal(window):
+	case e.window.Add(period).Equal(window):
+		e.previous, e.count = e.count, 0
+	default:
+		e.previous, e.count = 0, 0
+	}
+
+	e.window = window
+	e.expiration = window.Add(2 * period)
+}
+
+// trim drops the logged timestamps that are not after given cutoff.
+func (e *entry) trim(cutoff time.Time) {
+	i := 0
+	for i < len(e.log) && !e.log[i].After(cutoff) {
+		i++
+	}
+	e.log = e.log[i:]
+}
+
+// getWindow returns the start of the window containing now, aligned on the Unix epoch.
+func getWindow(now time.Time, period time.Duration) time.Time {
+	if period <= 0 {
+		return now
+	}
+	return time.Unix(0, now.UnixNano()-now.UnixNano()%int64(period))
+}
+
+type shard struct {
+	mutex   sync.Mutex
+	entries map[string]*entry
+	blocks  map[string]time.Time
+	leases  map[string]map[string]time.Time
+}
+
+// Cache is a sharded map of counters with expiration.
+// Each shard is protected by its own mutex to reduce contention between keys.
+type Cache struct {
+	shards  [shardCount]*shard
+	cleaner *cleaner
+}
+
+// CacheWrapper is used to ensure that the underlying cleaner goroutine used to clean expired keys will not prevent
+// Cache from being garbage collected.
+type CacheWrapper struct {
+	*Cache
+}
+
+func NewCache(cleanInterval time.Duration) *CacheWrapper {
+	cache := &Cache{}
+	for i := range cache.shards {
+		cache.shards[i] = &shard{
+			entries: make(map[string]*entry),
+			blocks:  make(map[string]time.Time),
+			leases:  make(map[string]map[string]time.Time),
+		}
+	}
+
+	wrapper := &CacheWrapper{Cache: cache}
+
+	if cleanInterval > 0 {
+		startCleaner(cache, cleanInterval)
+		runtime.SetFinalizer(wrapper, stopCleaner)
+	}
+
+	return wrapper
+}
+
+func (cache *Cache) getShard(key string) *shard {
+	// Inline FNV-1a, avoiding the allocation of hash/fnv.
+	hash := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= 1099511628211
+	}
+	return cache.shards[hash%shardCount]
+}
+
+// Increment increments the counter of given key by value, starting a new window of given duration
+// if the key does not exist or is expired. It returns the counter, its expiration and whether the
+// increment was applied. A weighted increment, of more than one, is not applied if it would exceed
+// the limit, and a negative one does not bring the counter below zero.
+func (cache *Cache) Increment(key string, value int64, duration time.Duration, limit int64) (int64, time.Time, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		if value > 1 && value > limit {
+			return 0, now.Add(duration), false
+		}
+		if value < 0 {
+			return 0, now.Add(duration), true
+		}
+		item = &entry{
+			count:      value,
+			expiration: now.Add(duration),
+		}
+		shard.entries[key] = item
+		return item.count, item.expiration, true
+	}
+
+	if value > 1 && item.count+value > limit {
+		return item.count, item.expiration, false
+	}
+
+	item.count = max(0, item.count+value)
+	return item.count, item.expiration, true
+}
+
+// IncrementSlidingWindow increments the current window counter of given key by value, unless the
+// sliding window estimate would exceed the rate limit, without bringing it below zero. It returns the
+// window start, the previous and current counters, and whether the increment was applied.
+func (cache *Cache) IncrementSlidingWindow(
+	key string,
+	value int64,
+	rate limiter.Rate,
+) (time.Time, int64, int64, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	window := getWindow(now, rate.Period)
+
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		item = &entry{}
+	}
+	item.slide(window, rate.Period)
+
+	count := common.GetSlidingWindowCount(now, rate, window, item.previous, item.count)
+	if value > 0 && count+value > rate.Limit {
+		return window, item.previous, item.count, false
+	}
+
+	item.count = max(0, item.count+value)
+	shard.entries[key] = item
+
+	return window, item.previous, item.count, true
+}
+
+// GetSlidingWindow returns the window start, the previous and current counters of given key,
+// without modifying them.
+func (cache *Cache) GetSlidingWindow(key string, period time.Duration) (time.Time, int64, int64) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	window := getWindow(now, period)
+
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		return window, 0, 0
+	}
+
+	state := *item
+	state.slide(window, period)
+
+	return window, state.previous, state.count
+}
+
+// IncrementGCRA advances the theoretical arrival time of given key by value emission intervals, unless
+// the request would exceed the burst tolerance. It returns the theoretical arrival time and whether
+// the increment was applied. The key expires once its theoretical arrival time has passed.
+func (cache *Cache) IncrementGCRA(key string, value int64, rate limiter.Rate) (time.Time, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	interval, tolerance, _ := common.GetGCRAParams(rate)
+
+	tat := now
+	item, ok := shard.entries[key]
+	if ok && !item.expired(now) {
+		tat = item.expiration
+	}
+
+	next := tat.Add(time.Duration(value) * interval)
+	if value > 0 && next.Sub(now) > tolerance {
+		return tat, false
+	}
+
+	if !next.After(now) {
+		delete(shard.entries, key)
+		return now, true
+	}
+
+	shard.entries[key] = &entry{
+		expiration: next,
+	}
+
+	return next, true
+}
+
+// GetGCRA returns the theoretical arrival time of given key, without modifying it.
+func (cache *Cache) GetGCRA(key string) time.Time {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		return now
+	}
+
+	return item.expiration
+}
+
+// IncrementSlidingLog records value timestamps for given key, unless the log would exceed the rate
+// limit, and keeps at most size timestamps. A negative value removes the most recent timestamps.
+// It returns the oldest timestamp, the number of timestamps and whether the increment was applied.
+func (cache *Cache) IncrementSlidingLog(
+	key string,
+	value int64,
+	rate limiter.Rate,
+	size int64,
+) (time.Time, int64, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		item = &entry{}
+	}
+	item.trim(now.Add(-rate.Period))
+
+	total := int64(len(item.log))
+	allowed := true
+
+	switch {
+	case value > 0 && total+value > rate.Limit:
+		allowed = false
+	case value > 0:
+		for i := int64(0); i < value; i++ {
+			item.log = append(item.log, now)
+		}
+	case value < 0:
+		if -value < total {
+			item.log = item.log[:total+value]
+		} else {
+			item.log = nil
+		}
+	}
+
+	if int64(len(item.log)) > size {
+		item.log = item.log[int64(len(item.log))-size:]
+	}
+
+	if len(item.log) == 0 {
+		delete(shard.entries, key)
+		return now, 0, allowed
+	}
+
+	item.expiration = item.log[len(item.log)-1].Add(rate.Period)
+	shard.entries[key] = item
+
+	return item.log[0], int64(len(item.log)), allowed
+}
+
+// GetSlidingLog returns the oldest timestamp and the number of timestamps of given key,
+// without modifying them.
+func (cache *Cache) GetSlidingLog(key string, period time.Duration) (time.Time, int64) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		return now, 0
+	}
+
+	state := *item
+	state.trim(now.Add(-period))
+	if len(state.log) == 0 {
+		return now, 0
+	}
+
+	return state.log[0], int64(len(state.log))
+}
+
+// Get returns the counter of given key and its expiration, without modifying it.
+func (cache *Cache) Get(key string, duration time.Duration) (int64, time.Time) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	item, ok := shard.entries[key]
+	if !ok || item.expired(now) {
+		return 0, now.Add(duration)
+	}
+
+	return item.count, item.expiration
+}
+
+// Reset removes the counter of given key.
+func (cache *Cache) Reset(key string, duration time.Duration) (int64, time.Time) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	delete(shard.entries, key)
+	delete(shard.entries, getMultiKey(key, duration))
+	delete(shard.blocks, key)
+
+	return 0, time.Now().Add(duration)
+}
+
+// Block rejects given key for duration, unless it is already blocked. It returns the end of the block.
+func (cache *Cache) Block(key string, duration time.Duration) time.Time {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	until, ok := shard.blocks[key]
+	if ok && now.Before(until) {
+		return until
+	}
+
+	until = now.Add(duration)
+	shard.blocks[key] = until
+
+	return until
+}
+
+// BlockedUntil returns the end of the block of given key, or the zero time if it is not blocked.
+func (cache *Cache) BlockedUntil(key string) time.Time {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	until, ok := shard.blocks[key]
+	if !ok || !time.Now().Before(until) {
+		return time.Time{}
+	}
+
+	return until
+}
+
+// IncrementMulti increments the counter of given key for every rate by value, only if none of them
+// would exceed its limit. The counters are kept in the shard of the key, so that they are updated
+// atomically. A negative value does not bring the counters below zero. It returns the counters, their
+// expiration and whether the increment was applied. A zero value only reads the counters.
+func (cache *Cache) IncrementMulti(key string, value int64, rates []limiter.Rate) ([]int64, []time.Time, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	now := time.Now()
+	items := make([]*entry, len(rates))
+	counts := make([]int64, len(rates))
+	expirations := make([]time.Time, len(rates))
+	ok := true
+
+	for i, rate := range rates {
+		item, found := shard.entries[getMultiKey(key, rate.Period)]
+		if !found || item.expired(now) {
+			item = &entry{expiration: now.Add(rate.GetWindowTTL(now))}
+		}
+
+		items[i] = item
+		counts[i] = item.count
+		expirations[i] = item.expiration
+		if item.count+value > rate.Limit {
+			ok = false
+		}
+	}
+
+	if !ok || value == 0 {
+		return counts, expirations, ok
+	}
+
+	for i, rate := range rates {
+		items[i].count = max(0, items[i].count+value)
+		counts[i] = items[i].count
+		shard.entries[getMultiKey(key, rate.Period)] = items[i]
+	}
+
+	return counts, expirations, true
+}
+
+// getMultiKey returns the key of the counter of given period, when several rates are applied to a key.
+func getMultiKey(key string, period time.Duration) string {
+	return key + ":" + strconv.FormatInt(period.Milliseconds(), 10)
+}
+
+// Acquire adds the lease id to given key until its expiration, unless the key holds limit leases.
+// It returns the number of leases held by the key and whether the lease was added.
+func (cache *Cache) Acquire(key string, id string, limit int64, expiration time.Time) (int64, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	leases := shard.getLeases(key, time.Now())
+	if int64(len(leases)) >= limit {
+		return int64(len(leases)), false
+	}
+
+	if leases == nil {
+		leases = make(map[string]time.Time)
+		shard.leases[key] = leases
+	}
+	leases[id] = expiration
+
+	return int64(len(leases)), true
+}
+
+// Renew moves the expiration of the lease id of given key, if it has not expired. It returns the
+// number of leases held by the key and whether the lease was renewed.
+func (cache *Cache) Renew(key string, id string, expiration time.Time) (int64, bool) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	leases := shard.getLeases(key, time.Now())
+	if _, ok := leases[id]; !ok {
+		return int64(len(leases)), false
+	}
+	leases[id] = expiration
+
+	return int64(len(leases)), true
+}
+
+// Release removes the lease id of given key.
+func (cache *Cache) Release(key string, id string) {
+	shard := cache.getShard(key)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	delete(shard.leases[key], id)
+	if len(shard.leases[key]) == 0 {
+		delete(shard.leases, key)
+	}
+}
+
+// getLeases returns the leases of given key, without the expired ones.
+func (shard *shard) getLeases(key string, now time.Time) map[string]time.Time {
+	leases := shard.leases[key]
+	for id, expiration := range leases {
+		if !now.Before(expiration) {
+			delete(leases, id)
+		}
+	}
+	return leases
+}
+
+// Clean removes every expired key.
+func (cache *Cache) Clean() {
+	now := time.Now()
+	for _, shard := range cache.shards {
+		shard.mutex.Lock()
+		for key, item := range shard.entries {
+			if item.expired(now) {
+				delete(shard.entries, key)
+			}
+		}
+		for key, until := range shard.blocks {
+			if !now.Before(until) {
+				delete(shard.blocks, key)
+			}
+		}
+		for key := range shard.leases {
+			if len(shard.getLeases(key, now)) == 0 {
+				delete(shard.leases, key)
+			}
+		}
+		shard.mutex.Unlock()
+	}
+}
+
+type cleaner struct {
+	interval time.Duration
+	stop     chan bool
+}
+
+func (cleaner *cleaner) run(cache *Cache) {
+	ticker := time.NewTicker(cleaner.interval)
+	for {
+		select {
+		case <-ticker.C:
+			cache.Clean()
+		case <-cleaner.stop:
+			ticker.Stop()
+			return
+		}
+	}
+}
+
+func startCleaner(cache *Cache, interval time.Duration) {
+	cleaner := &cleaner{
+		interval: interval,
+		stop:     make(chan bool),
+	}
+
+	cache.cleaner = cleaner
+	go cleaner.run(cache)
+}
+
+func stopCleaner(wrapper *CacheWrapper) {
+	wrapper.cleaner.stop <- true
+	wrapper.cleaner = nil
+}
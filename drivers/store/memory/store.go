@@ -0,0 +1,187 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+type Store struct {
+	Prefix     string
+	MaxLogSize int64
+	cache      *CacheWrapper
+}
+
+func NewStore() limiter.Store {
+	return NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          limiter.DefaultPrefix,
+		CleanUpInterval: limiter.DefaultCleanUpInterval,
+		MaxLogSize:      limiter.DefaultMaxLogSize,
+	})
+}
+
+func NewStoreWithOptions(options limiter.StoreOptions) limiter.Store {
+	return newStore(options)
+}
+
+// NewConcurrencyStore returns a store keeping the leases of concurrency limiters in memory.
+func NewConcurrencyStore() limiter.ConcurrencyStore {
+	return NewConcurrencyStoreWithOptions(limiter.StoreOptions{
+		Prefix:          limiter.DefaultPrefix,
+		CleanUpInterval: limiter.DefaultCleanUpInterval,
+	})
+}
+
+func NewConcurrencyStoreWithOptions(options limiter.StoreOptions) limiter.ConcurrencyStore {
+	return newStore(options)
+}
+
+func newStore(options limiter.StoreOptions) *Store {
+	store := &Store{
+		Prefix:     options.Prefix,
+		MaxLogSize: options.MaxLogSize,
+		cache:      NewCache(options.CleanUpInterval),
+	}
+
+	if store.MaxLogSize <= 0 {
+		store.MaxLogSize = limiter.DefaultMaxLogSize
+	}
+
+	return store
+}
+
+func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	return store.Inc(ctx, key, 1, rate)
+}
+
+func (store *Store) Inc(ctx context.Context, key string, count int64, rate limiter.Rate) (limiter.Context, error) {
+	cacheKey := store.getCacheKey(key)
+
+	blockedUntil := store.cache.BlockedUntil(cacheKey)
+	if !blockedUntil.IsZero() {
+		return common.GetBlockedContextFromState(common.GetLimit(rate), blockedUntil), nil
+	}
+
+	lctx, exceeded, err := store.inc(cacheKey, count, rate)
+	if err != nil || !exceeded || rate.Block <= 0 {
+		return lctx, err
+	}
+
+	blockedUntil = store.cache.Block(cacheKey, rate.Block)
+	return common.GetBlockedContextFromState(lctx.Limit, blockedUntil), nil
+}
+
+func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	cacheKey := store.getCacheKey(key)
+
+	blockedUntil := store.cache.BlockedUntil(cacheKey)
+	if !blockedUntil.IsZero() {
+		return common.GetBlockedContextFromState(common.GetLimit(rate), blockedUntil), nil
+	}
+
+	return store.peek(cacheKey, rate), nil
+}
+
+func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
+	count, _ := store.cache.Reset(store.getCacheKey(key), rate.Period)
+	now := time.Now()
+	return common.GetContextFromState(now, rate, now.Add(rate.GetWindowTTL(now)), count), nil
+}
+
+func (store *Store) IncMulti(ctx context.Context, key string, count int64, rates []limiter.Rate) (limiter.Context, error) {
+	err := common.CheckMultiRates(rates)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	counts, expirations, ok := store.cache.IncrementMulti(store.getCacheKey(key), count, rates)
+	return common.GetMultiContextFromState(time.Now(), rates, counts, expirations, count, ok), nil
+}
+
+func (store *Store) PeekMulti(ctx context.Context, key string, rates []limiter.Rate) (limiter.Context, error) {
+	return store.IncMulti(ctx, key, 0, rates)
+}
+
+func (store *Store) Acquire(ctx context.Context, key string, limit int64, ttl time.Duration) (limiter.Lease, error) {
+	lease := limiter.Lease{
+		Key:       key,
+		ID:        limiter.NewLeaseID(),
+		Limit:     limit,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+
+	lease.InFlight, lease.Acquired = store.cache.Acquire(store.getCacheKey(key), lease.ID, limit, lease.ExpiresAt)
+	return lease, nil
+}
+
+func (store *Store) Renew(ctx context.Context, lease limiter.Lease, ttl time.Duration) (limiter.Lease, error) {
+	lease.ExpiresAt = time.Now().Add(ttl)
+	lease.InFlight, lease.Acquired = store.cache.Renew(store.getCacheKey(lease.Key), lease.ID, lease.ExpiresAt)
+	return lease, nil
+}
+
+func (store *Store) Release(ctx context.Context, lease limiter.Lease) error {
+	store.cache.Release(store.getCacheKey(lease.Key), lease.ID)
+	return nil
+}
+
+// inc increments the key, and returns its context and whether the request exceeded the limit, which
+// starts the block of the rate. A weighted request rejected by the fixed window is not counted, and does
+// not exceed the limit.
+func (store *Store) inc(key string, count int64, rate limiter.Rate) (limiter.Context, bool, error) {
+	switch rate.Algorithm {
+	case limiter.SlidingWindow:
+		window, previous, current, ok := store.cache.IncrementSlidingWindow(key, count, rate)
+		return common.GetSlidingWindowContextFromState(time.Now(), rate, window, previous, current, !ok), !ok, nil
+	case limiter.GCRA:
+		tat, ok := store.cache.IncrementGCRA(key, count, rate)
+		return common.GetGCRAContextFromState(time.Now(), rate, tat, count, !ok), !ok, nil
+	case limiter.SlidingLog:
+		err := common.CheckSlidingLogRate(rate, store.MaxLogSize)
+		if err != nil {
+			return limiter.Context{}, false, err
+		}
+		oldest, total, ok := store.cache.IncrementSlidingLog(key, count, rate, store.MaxLogSize)
+		return common.GetSlidingLogContextFromState(time.Now(), rate, oldest, total, !ok), !ok, nil
+	}
+
+	count, expiration, ok := store.cache.Increment(key, count, rate.GetWindowTTL(time.Now()), rate.Limit)
+	lctx := common.GetContextFromState(time.Now(), rate, expiration, count)
+	if !ok {
+		lctx.Reached = true
+		return lctx, false, nil
+	}
+	return lctx, lctx.Reached, nil
+}
+
+func (store *Store) peek(key string, rate limiter.Rate) limiter.Context {
+	switch rate.Algorithm {
+	case limiter.SlidingWindow:
+		window, previous, current := store.cache.GetSlidingWindow(key, rate.Period)
+		now := time.Now()
+		reached := common.GetSlidingWindowCount(now, rate, window, previous, current) >= rate.Limit
+		return common.GetSlidingWindowContextFromState(now, rate, window, previous, current, reached)
+	case limiter.GCRA:
+		tat := store.cache.GetGCRA(key)
+		lctx := common.GetGCRAContextFromState(time.Now(), rate, tat, 0, false)
+		lctx.Reached = lctx.Remaining == 0
+		return lctx
+	case limiter.SlidingLog:
+		oldest, total := store.cache.GetSlidingLog(key, rate.Period)
+		return common.GetSlidingLogContextFromState(time.Now(), rate, oldest, total, total >= rate.Limit)
+	}
+
+	count, expiration := store.cache.Get(key, rate.GetWindowTTL(time.Now()))
+	return common.GetContextFromState(time.Now(), rate, expiration, count)
+}
+
+func (store *Store) getCacheKey(key string) string {
+	buffer := strings.Builder{}
+	buffer.WriteString(store.Prefix)
+	buffer.WriteString(":")
+	buffer.WriteString(key)
+	return buffer.String()
+}
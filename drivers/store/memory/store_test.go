@@ -0,0 +1,138 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/memory"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/tests"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreSequentialAccess(t *testing.T) {
+	tests.TestStoreSequentialAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:sequential-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreSlidingWindowAccess(t *testing.T) {
+	tests.TestStoreSlidingWindowAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:sliding-window-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreGCRAAccess(t *testing.T) {
+	tests.TestStoreGCRAAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:gcra-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreSlidingLogAccess(t *testing.T) {
+	tests.TestStoreSlidingLogAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:sliding-log-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreBlockAccess(t *testing.T) {
+	tests.TestStoreBlockAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:block-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreWeightedAccess(t *testing.T) {
+	tests.TestStoreWeightedAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:weighted-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreGrantAccess(t *testing.T) {
+	tests.TestStoreGrantAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:grant-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreMultiRateAccess(t *testing.T) {
+	tests.TestStoreMultiRateAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:multi-rate-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreCalendarAccess(t *testing.T) {
+	tests.TestStoreCalendarAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:calendar-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryConcurrencyStoreAccess(t *testing.T) {
+	tests.TestConcurrencyStoreAccess(t, memory.NewConcurrencyStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:concurrency-test",
+		CleanUpInterval: 30 * time.Second,
+	}))
+}
+
+func TestMemoryStoreConcurrentAccess(t *testing.T) {
+	tests.TestStoreConcurrentAccess(t, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:concurrent-test",
+		CleanUpInterval: 1 * time.Second,
+	}))
+}
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	store := memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:expiration-test",
+		CleanUpInterval: 10 * time.Millisecond,
+	})
+
+	rate := limiter.Rate{
+		Limit:  1,
+		Period: 100 * time.Millisecond,
+	}
+
+	lctx, err := store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.False(lctx.Reached)
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.True(lctx.Reached)
+
+	time.Sleep(150 * time.Millisecond)
+
+	lctx, err = store.Peek(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(1), lctx.Remaining)
+	is.False(lctx.Reached)
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(0), lctx.Remaining)
+	is.False(lctx.Reached)
+}
+
+func BenchmarkMemoryStoreSequentialAccess(b *testing.B) {
+	tests.BenchmarkStoreSequentialAccess(b, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:sequential-benchmark",
+		CleanUpInterval: 1 * time.Hour,
+	}))
+}
+
+func BenchmarkMemoryStoreConcurrentAccess(b *testing.B) {
+	tests.BenchmarkStoreConcurrentAccess(b, memory.NewStoreWithOptions(limiter.StoreOptions{
+		Prefix:          "limiter:memory:concurrent-benchmark",
+		CleanUpInterval: 1 * time.Hour,
+	}))
+}
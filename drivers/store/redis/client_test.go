@@ -0,0 +1,93 @@
+package redis_test
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/redis"
+)
+
+// fakeClient answers scripts like a node which may lose them, as after a failover in a cluster.
+type fakeClient struct {
+	redis.Client
+	mutex   sync.Mutex
+	scripts map[string]bool
+	loads   int
+	keys    [][]string
+}
+
+func (client *fakeClient) ScriptLoad(ctx context.Context, script string) *libredis.StringCmd {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	hash := sha1.Sum([]byte(script))
+	sha := hex.EncodeToString(hash[:])
+	client.scripts[sha] = true
+	client.loads++
+
+	cmd := libredis.NewStringCmd(ctx)
+	cmd.SetVal(sha)
+	return cmd
+}
+
+func (client *fakeClient) EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) *libredis.Cmd {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	client.keys = append(client.keys, keys)
+
+	cmd := libredis.NewCmd(ctx)
+	if !client.scripts[sha] {
+		cmd.SetErr(errors.New("NOSCRIPT No matching script. Please use EVAL."))
+		return cmd
+	}
+	cmd.SetVal([]interface{}{int64(1), int64(60000), int64(0)})
+	return cmd
+}
+
+func (client *fakeClient) flush() {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+	client.scripts = make(map[string]bool)
+}
+
+func TestRedisStoreScriptReload(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	client := &fakeClient{scripts: make(map[string]bool)}
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:reload-test",
+	})
+	is.NoError(err)
+	is.Equal(9, client.loads)
+
+	rate := limiter.Rate{Limit: 10, Period: 1 * time.Minute}
+
+	lctx, err := store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(9), lctx.Remaining)
+
+	// The node serving the key lost the scripts: they are loaded again and the call is retried.
+	client.flush()
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.Equal(int64(9), lctx.Remaining)
+	is.Equal(18, client.loads)
+
+	// Both keys of a script share the hash tag, and so the slot of a cluster.
+	is.Len(client.keys, 3)
+	for _, keys := range client.keys {
+		is.Equal([]string{"limiter:redis:reload-test:{foo}", "limiter:redis:reload-test:{foo}:blocked"}, keys)
+	}
+}
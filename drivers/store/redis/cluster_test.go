@@ -0,0 +1,95 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	libredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/redis"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/tests"
+)
+
+// newClusterStore returns a store on the cluster whose nodes are listed in REDIS_CLUSTER_ADDRS,
+// e.g. "localhost:7000,localhost:7001,localhost:7002", or skips the test if it is not set.
+func newClusterStore(t *testing.T, name string) limiter.Store {
+	addrs := os.Getenv("REDIS_CLUSTER_ADDRS")
+	if addrs == "" {
+		t.Skip("REDIS_CLUSTER_ADDRS is not set")
+	}
+
+	client := libredis.NewClusterClient(&libredis.ClusterOptions{
+		Addrs: strings.Split(addrs, ","),
+	})
+	t.Cleanup(func() {
+		_ = client.Close()
+	})
+
+	// Keys left by previous runs would change the counters.
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: fmt.Sprintf("limiter:redis:cluster-%s-test:%d", name, time.Now().UnixNano()),
+	})
+	require.NoError(t, err)
+
+	return store
+}
+
+func TestRedisClusterStoreSequentialAccess(t *testing.T) {
+	tests.TestStoreSequentialAccess(t, newClusterStore(t, "sequential"))
+}
+
+func TestRedisClusterStoreBlockAccess(t *testing.T) {
+	tests.TestStoreBlockAccess(t, newClusterStore(t, "block"))
+}
+
+func TestRedisClusterStoreMultiRateAccess(t *testing.T) {
+	tests.TestStoreMultiRateAccess(t, newClusterStore(t, "multi-rate"))
+}
+
+func TestRedisClusterStoreScan(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	store := newClusterStore(t, "scan")
+
+	// The keys are spread over the slots, and so over the masters, of the cluster.
+	fixed := limiter.NewLimiter(store, limiter.Rate{Limit: 100, Period: time.Minute})
+	for i := 0; i < 20; i++ {
+		_, err := fixed.Inc(ctx, fmt.Sprintf("ip:192.168.0.%d", i), int64(i+1))
+		is.NoError(err)
+	}
+
+	states, err := limiter.ScanAll(ctx, store.(limiter.ScanStore), nil)
+	is.NoError(err)
+	is.Len(states, 20)
+
+	// Check that the masters are scanned one after the other, by batches.
+	keys := map[string]bool{}
+	cursor := uint64(0)
+	for calls := 1; ; calls++ {
+		is.Less(calls, 100)
+
+		batch, next, err := store.(limiter.ScanStore).Scan(ctx, cursor, 5)
+		is.NoError(err)
+		for _, state := range batch {
+			keys[state.Key] = true
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	is.Len(keys, 20)
+
+	top, err := limiter.TopKeys(ctx, store.(limiter.ScanStore), 1)
+	is.NoError(err)
+	is.Equal("ip:192.168.0.19", top[0].Key)
+	is.Equal(int64(20), top[0].Count)
+}
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+const (
+	// luaAcquireScript keeps the leases of a key in a sorted set, scored by their expiration in
+	// milliseconds. Expired leases are dropped before counting the others.
+	luaAcquireScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("zremrangebyscore", key, "-inf", now)
+local count = redis.call("zcard", key)
+if count >= limit then
+	return {0, count}
+end
+redis.call("zadd", key, now + ttl, ARGV[4])
+if redis.call("pttl", key) < ttl then
+	redis.call("pexpire", key, ttl)
+end
+return {1, count + 1}
+`
+	luaRenewScript = `
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local ttl = tonumber(ARGV[2])
+redis.call("zremrangebyscore", key, "-inf", now)
+if not redis.call("zscore", key, ARGV[3]) then
+	return {0, redis.call("zcard", key)}
+end
+redis.call("zadd", key, "xx", now + ttl, ARGV[3])
+if redis.call("pttl", key) < ttl then
+	redis.call("pexpire", key, ttl)
+end
+return {1, redis.call("zcard", key)}
+`
+	luaReleaseScript = `
+redis.call("zrem", KEYS[1], ARGV[1])
+return {1, redis.call("zcard", KEYS[1])}
+`
+)
+
+// NewConcurrencyStore returns a store keeping the leases of concurrency limiters in redis.
+func NewConcurrencyStore(client Client) (limiter.ConcurrencyStore, error) {
+	return NewConcurrencyStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: limiter.DefaultPrefix,
+	})
+}
+
+func NewConcurrencyStoreWithOptions(client Client, options limiter.StoreOptions) (limiter.ConcurrencyStore, error) {
+	store, err := NewStoreWithOptions(client, options)
+	if err != nil {
+		return nil, err
+	}
+	return store.(*Store), nil
+}
+
+func (store *Store) Acquire(ctx context.Context, key string, limit int64, ttl time.Duration) (limiter.Lease, error) {
+	now := time.Now()
+	lease := limiter.Lease{
+		Key:       key,
+		ID:        limiter.NewLeaseID(),
+		Limit:     limit,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	cmd := store.evalSHA(ctx, store.getLuaAcquireSHA, []string{store.getLeasesKey(key)},
+		now.UnixMilli(), ttl.Milliseconds(), limit, lease.ID)
+
+	var err error
+	lease.Acquired, lease.InFlight, err = parseLeaseState(cmd)
+	return lease, err
+}
+
+func (store *Store) Renew(ctx context.Context, lease limiter.Lease, ttl time.Duration) (limiter.Lease, error) {
+	now := time.Now()
+	lease.ExpiresAt = now.Add(ttl)
+
+	cmd := store.evalSHA(ctx, store.getLuaRenewSHA, []string{store.getLeasesKey(lease.Key)},
+		now.UnixMilli(), ttl.Milliseconds(), lease.ID)
+
+	var err error
+	lease.Acquired, lease.InFlight, err = parseLeaseState(cmd)
+	return lease, err
+}
+
+func (store *Store) Release(ctx context.Context, lease limiter.Lease) error {
+	cmd := store.evalSHA(ctx, store.getLuaReleaseSHA, []string{store.getLeasesKey(lease.Key)}, lease.ID)
+	_, _, err := parseLeaseState(cmd)
+	return err
+}
+
+func (store *Store) getLeasesKey(key string) string {
+	return store.getCacheKey(key) + ":leases"
+}
+
+func parseLeaseState(cmd *libredis.Cmd) (bool, int64, error) {
+	result, err := cmd.Result()
+	if err != nil {
+		return false, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	fields, ok := result.([]interface{})
+	if !ok || len(fields) != 2 {
+		return false, 0, errors.New("two elements in result were expected")
+	}
+
+	acquired, ok1 := fields[0].(int64)
+	count, ok2 := fields[1].(int64)
+	if !ok1 || !ok2 {
+		return false, 0, errors.New("type of the lease state and count should be number")
+	}
+
+	return acquired == 1, count, nil
+}
+
+func (store *Store) getLuaAcquireSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaAcquireSHA
+}
+
+func (store *Store) getLuaRenewSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaRenewSHA
+}
+
+func (store *Store) getLuaReleaseSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaReleaseSHA
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+// luaGCRAScript keeps the theoretical arrival time of a key, in microseconds, until it has passed,
+// followed by the emission interval, e.g. "1735689600000000:6000000", from which Scan derives the usage
+// of the key. The increment is only applied if it stays within the burst tolerance.
+// A zero count only reads the state.
+const luaGCRAScript = `
+local key = KEYS[1]
+local count = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local interval = tonumber(ARGV[3])
+local tolerance = tonumber(ARGV[4])
+local state = redis.call("get", key)
+local tat = state and tonumber(string.match(state, "^[^:]+")) or now
+if tat < now then
+	tat = now
+end
+if blocked > 0 then
+	return {tat, 0, blocked}
+end
+if count == 0 then
+	return {tat, 1, 0}
+end
+local next = tat + count * interval
+if count > 0 and next - now > tolerance then
+	return {tat, 0, start_block()}
+end
+local ttl = math.ceil((next - now) / 1000)
+if ttl > 0 then
+	redis.call("set", key, string.format("%.0f:%.0f", next, interval), "px", ttl)
+else
+	redis.call("del", key)
+	next = now
+end
+return {next, 1, 0}
+`
+
+func (store *Store) incGCRA(
+	ctx context.Context,
+	key string,
+	count int64,
+	rate limiter.Rate,
+) (limiter.Context, error) {
+	now := time.Now()
+	interval, tolerance, burst := common.GetGCRAParams(rate)
+
+	cmd := store.evalSHA(ctx, store.getLuaGCRASHA, store.getKeys(key),
+		count, now.UnixMicro(), float64(interval)/float64(time.Microsecond), tolerance.Microseconds(),
+		rate.Block.Milliseconds())
+
+	tat, ok, blocked, err := parseGCRAState(cmd)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	if blocked > 0 {
+		return common.GetBlockedContextFromState(burst, getBlockedUntil(now, blocked)), nil
+	}
+
+	lctx := common.GetGCRAContextFromState(now, rate, tat, count, !ok)
+	if count == 0 {
+		lctx.Reached = lctx.Remaining == 0
+	}
+
+	return lctx, nil
+}
+
+func (store *Store) getLuaGCRASHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaGCRASHA
+}
+
+func parseGCRAState(cmd *libredis.Cmd) (time.Time, bool, int64, error) {
+	result, err := cmd.Result()
+	if err != nil {
+		return time.Time{}, false, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	fields, ok := result.([]interface{})
+	if !ok || len(fields) != 3 {
+		return time.Time{}, false, 0, errors.New("three elements in result were expected")
+	}
+
+	tat, ok1 := fields[0].(int64)
+	state, ok2 := fields[1].(int64)
+	blocked, ok3 := fields[2].(int64)
+	if !ok1 || !ok2 || !ok3 {
+		return time.Time{}, false, 0, errors.New("type of the arrival time, state and/or block should be number")
+	}
+
+	return time.UnixMicro(tat), state == 1, blocked, nil
+}
@@ -0,0 +1,126 @@
+package redis
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+// luaMultiScript keeps a fixed window counter per rate, in KEYS, with the window length in milliseconds
+// and the limit of each rate in ARGV. The counters are only incremented if none of them would exceed its
+// limit. A zero count only reads the counters.
+const luaMultiScript = `
+local count = tonumber(ARGV[1])
+local allowed = 1
+local counts = {}
+local ttls = {}
+for i, key in ipairs(KEYS) do
+	counts[i] = tonumber(redis.call("get", key)) or 0
+	ttls[i] = redis.call("pttl", key)
+	if counts[i] + count > tonumber(ARGV[2 * i + 1]) then
+		allowed = 0
+	end
+end
+if allowed == 1 and count ~= 0 then
+	for i, key in ipairs(KEYS) do
+		local delta = math.max(count, -counts[i])
+		if delta ~= 0 then
+			counts[i] = redis.call("incrby", key, delta)
+			if ttls[i] < 0 then
+				ttls[i] = tonumber(ARGV[2 * i])
+				redis.call("pexpire", key, ttls[i])
+			end
+		end
+	end
+end
+local result = {allowed}
+for i = 1, #KEYS do
+	table.insert(result, counts[i])
+	table.insert(result, ttls[i])
+end
+return result
+`
+
+func (store *Store) IncMulti(ctx context.Context, key string, count int64, rates []limiter.Rate) (limiter.Context, error) {
+	err := common.CheckMultiRates(rates)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	keys := make([]string, len(rates))
+	args := make([]interface{}, 0, 1+2*len(rates))
+	args = append(args, count)
+	now := time.Now()
+	for i, rate := range rates {
+		keys[i] = store.getMultiKey(key, rate.Period)
+		args = append(args, common.GetWindowTTLMilliseconds(rate, now), rate.Limit)
+	}
+
+	cmd := store.evalSHA(ctx, store.getLuaMultiSHA, keys, args...)
+	ok, counts, ttls, err := parseMultiState(cmd, len(rates))
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	now = time.Now()
+	expirations := make([]time.Time, len(rates))
+	for i, rate := range rates {
+		expirations[i] = now.Add(rate.GetWindowTTL(now))
+		if ttls[i] > 0 && rate.Calendar == "" {
+			expirations[i] = now.Add(time.Duration(ttls[i]) * time.Millisecond)
+		}
+	}
+
+	return common.GetMultiContextFromState(now, rates, counts, expirations, count, ok), nil
+}
+
+func (store *Store) PeekMulti(ctx context.Context, key string, rates []limiter.Rate) (limiter.Context, error) {
+	return store.IncMulti(ctx, key, 0, rates)
+}
+
+// getMultiKey returns the key of the counter of given period, when several rates are applied to a key.
+func (store *Store) getMultiKey(key string, period time.Duration) string {
+	return store.getCacheKey(key) + ":" + strconv.FormatInt(period.Milliseconds(), 10)
+}
+
+func (store *Store) getLuaMultiSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaMultiSHA
+}
+
+func parseMultiState(cmd *libredis.Cmd, size int) (bool, []int64, []int64, error) {
+	result, err := cmd.Result()
+	if err != nil {
+		return false, nil, nil, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	fields, ok := result.([]interface{})
+	if !ok || len(fields) != 1+2*size {
+		return false, nil, nil, errors.Errorf("%d elements in result were expected", 1+2*size)
+	}
+
+	values := make([]int64, len(fields))
+	for i := range fields {
+		value, ok := fields[i].(int64)
+		if !ok {
+			return false, nil, nil, errors.New("type of the state, counters and ttls should be number")
+		}
+		values[i] = value
+	}
+
+	counts := make([]int64, size)
+	ttls := make([]int64, size)
+	for i := 0; i < size; i++ {
+		counts[i] = values[1+2*i]
+		ttls[i] = values[2+2*i]
+	}
+
+	return values[0] == 1, counts, ttls, nil
+}
@@ -0,0 +1,203 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// clusterCursorShift is the position of the index of the master in the cursors of a Redis Cluster,
+// whose lower bits hold the cursor of the master.
+const clusterCursorShift = 48
+
+var _ limiter.ScanStore = (*Store)(nil)
+
+// Scan lists the keys of the store with SCAN, which does not block the server like KEYS. The states of
+// a key, its rates and its block, are merged when they are returned by the same batch. Keys limited by
+// the GCRA algorithm, which stores an arrival time instead of a counter, report the number of emission
+// intervals it is ahead of now, and the leases of concurrency limiters are skipped.
+func (store *Store) Scan(ctx context.Context, cursor uint64, count int64) ([]limiter.KeyState, uint64, error) {
+	keys, next, err := store.scanKeys(ctx, cursor, escapePattern(store.Prefix)+":{*", count)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	types := make([]*libredis.StatusCmd, len(keys))
+	ttls := make([]*libredis.DurationCmd, len(keys))
+	_, err = store.client.Pipelined(ctx, func(pipe libredis.Pipeliner) error {
+		for i, key := range keys {
+			types[i] = pipe.Type(ctx, key)
+			ttls[i] = pipe.PTTL(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	counts := make([]libredis.Cmder, len(keys))
+	_, err = store.client.Pipelined(ctx, func(pipe libredis.Pipeliner) error {
+		for i, key := range keys {
+			switch types[i].Val() {
+			case "string":
+				counts[i] = pipe.Get(ctx, key)
+			case "hash":
+				counts[i] = pipe.HGet(ctx, key, "current")
+			case "zset":
+				counts[i] = pipe.ZCard(ctx, key)
+			}
+		}
+		return nil
+	})
+	// Keys expiring between the pipelines are missing, which is not an error.
+	if err != nil && err != libredis.Nil {
+		return nil, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	now := time.Now()
+	order := []string{}
+	states := make(map[string]limiter.KeyState, len(keys))
+	for i, raw := range keys {
+		key, suffix, ok := store.parseCacheKey(raw)
+		if !ok || suffix == ":leases" {
+			continue
+		}
+
+		state := limiter.KeyState{Key: key}
+		ttl := ttls[i].Val()
+		if ttl < 0 {
+			ttl = 0
+		}
+
+		if suffix == ":blocked" {
+			state.BlockedFor = ttl
+		} else {
+			state.TTL = ttl
+			state.Count = getCount(counts[i], now)
+		}
+
+		if previous, ok := states[key]; ok {
+			state = previous.Merge(state)
+		} else {
+			order = append(order, key)
+		}
+		states[key] = state
+	}
+
+	result := make([]limiter.KeyState, len(order))
+	for i, key := range order {
+		result[i] = states[key]
+	}
+	return result, next, nil
+}
+
+// scanKeys returns a batch of the keys matching the pattern. On a Redis Cluster, each master has its
+// own keys and cursor: the masters are scanned one after the other, the cursor holding the index of the
+// master, ordered by address, and its own cursor.
+func (store *Store) scanKeys(ctx context.Context, cursor uint64, pattern string,
+	count int64) ([]string, uint64, error) {
+
+	cluster, ok := store.client.(*libredis.ClusterClient)
+	if !ok {
+		keys, next, err := store.client.Scan(ctx, cursor, pattern, count).Result()
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "an error has occurred with redis command")
+		}
+		return keys, next, nil
+	}
+
+	var mutex sync.Mutex
+	masters := []*libredis.Client{}
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, client *libredis.Client) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+		masters = append(masters, client)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].Options().Addr < masters[j].Options().Addr
+	})
+
+	index := cursor >> clusterCursorShift
+	if index >= uint64(len(masters)) {
+		return nil, 0, errors.Errorf("invalid cursor %d: the cluster has %d masters", cursor, len(masters))
+	}
+
+	keys, next, err := masters[index].Scan(ctx, cursor&(1<<clusterCursorShift-1), pattern, count).Result()
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	if next >= 1<<clusterCursorShift {
+		return nil, 0, errors.Errorf("cursor %d of master %d is too large to be combined", next, index)
+	}
+
+	// The next master is scanned from the start, once the current one is done.
+	if next == 0 {
+		index++
+		if index == uint64(len(masters)) {
+			return keys, 0, nil
+		}
+	}
+	return keys, index<<clusterCursorShift | next, nil
+}
+
+// parseCacheKey returns the key whose state is stored at given redis key, and the suffix of the
+// derived key, such as ":blocked", or an empty suffix for the state itself.
+func (store *Store) parseCacheKey(raw string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(raw, store.Prefix+":{")
+	if !ok {
+		return "", "", false
+	}
+
+	end := strings.LastIndex(rest, "}")
+	if end < 0 {
+		return "", "", false
+	}
+	return rest[:end], rest[end+1:], true
+}
+
+func getCount(cmd libredis.Cmder, now time.Time) int64 {
+	switch cmd := cmd.(type) {
+	case *libredis.StringCmd:
+		if tat, interval, ok := strings.Cut(cmd.Val(), ":"); ok {
+			return getGCRACount(tat, interval, now)
+		}
+		count, err := strconv.ParseInt(cmd.Val(), 10, 64)
+		if err != nil {
+			return 0
+		}
+		return count
+	case *libredis.IntCmd:
+		return cmd.Val()
+	}
+	return 0
+}
+
+// getGCRACount returns the number of emission intervals the theoretical arrival time is ahead of now,
+// which is the number of requests the key would have to wait for to get its whole burst back.
+func getGCRACount(tat string, interval string, now time.Time) int64 {
+	arrival, err1 := strconv.ParseInt(tat, 10, 64)
+	emission, err2 := strconv.ParseInt(interval, 10, 64)
+	if err1 != nil || err2 != nil || emission <= 0 || arrival <= now.UnixMicro() {
+		return 0
+	}
+	return (arrival - now.UnixMicro() + emission - 1) / emission
+}
+
+// escapePattern escapes the special characters of the glob-style patterns of SCAN.
+func escapePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+	return replacer.Replace(value)
+}
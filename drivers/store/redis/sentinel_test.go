@@ -0,0 +1,86 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	libredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/redis"
+)
+
+// TestRedisSentinelStoreFailover runs against the master REDIS_SENTINEL_MASTER, monitored by the
+// sentinels listed in REDIS_SENTINEL_ADDRS, e.g. "localhost:26379,localhost:26380,localhost:26381",
+// and is skipped if they are not set. It forces a failover and checks that the counters are kept.
+func TestRedisSentinelStoreFailover(t *testing.T) {
+	addrs := os.Getenv("REDIS_SENTINEL_ADDRS")
+	master := os.Getenv("REDIS_SENTINEL_MASTER")
+	if addrs == "" || master == "" {
+		t.Skip("REDIS_SENTINEL_ADDRS or REDIS_SENTINEL_MASTER is not set")
+	}
+
+	is := require.New(t)
+	ctx := context.Background()
+
+	client := libredis.NewFailoverClient(&libredis.FailoverOptions{
+		MasterName:    master,
+		SentinelAddrs: strings.Split(addrs, ","),
+	})
+	defer func() {
+		_ = client.Close()
+	}()
+
+	sentinel := libredis.NewSentinelClient(&libredis.Options{
+		Addr: strings.Split(addrs, ",")[0],
+	})
+	defer func() {
+		_ = sentinel.Close()
+	}()
+
+	// Keys left by previous runs would change the counters.
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: fmt.Sprintf("limiter:redis:sentinel-failover-test:%d", time.Now().UnixNano()),
+	})
+	is.NoError(err)
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  10,
+		Period: time.Hour,
+	})
+
+	for i := 1; i <= 3; i++ {
+		lctx, err := limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(10-i), lctx.Remaining)
+	}
+
+	// The counter is replicated before the failover, so that the new master has it.
+	is.NoError(client.Do(ctx, "WAIT", 1, 5000).Err())
+
+	before, err := sentinel.GetMasterAddrByName(ctx, master).Result()
+	is.NoError(err)
+	is.NoError(sentinel.Failover(ctx, master).Err())
+
+	is.Eventually(func() bool {
+		after, err := sentinel.GetMasterAddrByName(ctx, master).Result()
+		return err == nil && strings.Join(after, ":") != strings.Join(before, ":")
+	}, time.Minute, 500*time.Millisecond)
+
+	// The client follows the new master, on which the scripts are loaded again.
+	is.Eventually(func() bool {
+		_, err := limiter.Peek(ctx, "foo")
+		return err == nil
+	}, time.Minute, 500*time.Millisecond)
+
+	for i := 4; i <= 5; i++ {
+		lctx, err := limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(10-i), lctx.Remaining)
+	}
+}
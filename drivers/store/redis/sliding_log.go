@@ -0,0 +1,124 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+// luaSlidingLogScript keeps the timestamp of every allowed request in a sorted set.
+// Members are suffixed by a zero padded sequence so requests sharing a millisecond stay distinct,
+// and a negative count removes the most recent timestamps.
+// A zero count only drops the timestamps that left the sliding period.
+const luaSlidingLogScript = `
+local key = KEYS[1]
+local count = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local limit = tonumber(ARGV[4])
+local size = tonumber(ARGV[5])
+redis.call("zremrangebyscore", key, "-inf", now - period)
+local total = redis.call("zcard", key)
+local allowed = 1
+if blocked > 0 then
+	allowed = 0
+elseif count > 0 then
+	if total + count > limit then
+		allowed = 0
+		blocked = start_block()
+	else
+		local offset = redis.call("zcount", key, now, now)
+		local members = {}
+		for i = 1, count do
+			table.insert(members, now)
+			table.insert(members, string.format("%d:%010d", now, offset + i))
+			if #members >= 1000 or i == count then
+				redis.call("zadd", key, unpack(members))
+				members = {}
+			end
+		end
+		total = total + count
+	end
+elseif count < 0 then
+	redis.call("zremrangebyrank", key, count, -1)
+	total = redis.call("zcard", key)
+end
+if total > size then
+	redis.call("zremrangebyrank", key, 0, total - size - 1)
+	total = size
+end
+if count ~= 0 and total > 0 then
+	redis.call("pexpire", key, period)
+end
+local oldest = now
+local first = redis.call("zrange", key, 0, 0, "withscores")
+if first[2] then
+	oldest = tonumber(first[2])
+end
+return {total, oldest, allowed, blocked}
+`
+
+func (store *Store) incSlidingLog(
+	ctx context.Context,
+	key string,
+	count int64,
+	rate limiter.Rate,
+) (limiter.Context, error) {
+	err := common.CheckSlidingLogRate(rate, store.MaxLogSize)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	now := time.Now()
+	cmd := store.evalSHA(ctx, store.getLuaSlidingLogSHA, store.getKeys(key),
+		count, rate.Period.Milliseconds(), now.UnixMilli(), rate.Limit, store.MaxLogSize, rate.Block.Milliseconds())
+
+	total, oldest, ok, blocked, err := parseSlidingLogState(cmd)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	if blocked > 0 {
+		return common.GetBlockedContextFromState(rate.Limit, getBlockedUntil(now, blocked)), nil
+	}
+
+	reached := !ok
+	if count == 0 {
+		reached = total >= rate.Limit
+	}
+
+	return common.GetSlidingLogContextFromState(now, rate, oldest, total, reached), nil
+}
+
+func (store *Store) getLuaSlidingLogSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaSlidingLogSHA
+}
+
+func parseSlidingLogState(cmd *libredis.Cmd) (int64, time.Time, bool, int64, error) {
+	result, err := cmd.Result()
+	if err != nil {
+		return 0, time.Time{}, false, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	fields, ok := result.([]interface{})
+	if !ok || len(fields) != 4 {
+		return 0, time.Time{}, false, 0, errors.New("four elements in result were expected")
+	}
+
+	total, ok1 := fields[0].(int64)
+	oldest, ok2 := fields[1].(int64)
+	state, ok3 := fields[2].(int64)
+	blocked, ok4 := fields[3].(int64)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return 0, time.Time{}, false, 0, errors.New("type of the total, oldest timestamp, state and/or block should be number")
+	}
+
+	return total, time.UnixMilli(oldest), state == 1, blocked, nil
+}
@@ -0,0 +1,114 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	libredis "github.com/redis/go-redis/v9"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+	"github.com/hgtpcastro/go-expert-lab-rate-limiter/drivers/store/common"
+)
+
+// luaSlidingWindowScript keeps the current and previous window counters in a hash.
+// The increment is only applied if the weighted estimate stays within the limit.
+// A zero count only reads the state. A stored window ahead of now was started by an instance whose
+// clock is ahead, and is kept as the current window rather than discarded.
+const luaSlidingWindowScript = `
+local key = KEYS[1]
+local count = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local limit = tonumber(ARGV[4])
+local window = now - (now % period)
+local state = redis.call("hmget", key, "window", "current", "previous")
+local last = tonumber(state[1])
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+if last and last > window then
+	window = last
+	now = window
+end
+if last ~= window then
+	if last == window - period then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+if blocked > 0 then
+	return {window, previous, current, 0, blocked}
+end
+if count == 0 then
+	return {window, previous, current, 1, 0}
+end
+local estimate = current
+if previous > 0 then
+	estimate = estimate + math.ceil(previous * (period - (now - window)) / period)
+end
+if count > 0 and estimate + count > limit then
+	return {window, previous, current, 0, start_block()}
+end
+current = math.max(0, current + count)
+redis.call("hset", key, "window", window, "current", current, "previous", previous)
+redis.call("pexpire", key, period * 2)
+return {window, previous, current, 1, 0}
+`
+
+func (store *Store) incSlidingWindow(
+	ctx context.Context,
+	key string,
+	count int64,
+	rate limiter.Rate,
+) (limiter.Context, error) {
+	now := time.Now()
+	cmd := store.evalSHA(ctx, store.getLuaSlidingWindowSHA, store.getKeys(key),
+		count, rate.Period.Milliseconds(), now.UnixMilli(), rate.Limit, rate.Block.Milliseconds())
+
+	window, previous, current, ok, blocked, err := parseSlidingWindowState(cmd)
+	if err != nil {
+		return limiter.Context{}, err
+	}
+
+	if blocked > 0 {
+		return common.GetBlockedContextFromState(rate.Limit, getBlockedUntil(now, blocked)), nil
+	}
+
+	reached := !ok
+	if count == 0 {
+		reached = common.GetSlidingWindowCount(now, rate, window, previous, current) >= rate.Limit
+	}
+
+	return common.GetSlidingWindowContextFromState(now, rate, window, previous, current, reached), nil
+}
+
+func (store *Store) getLuaSlidingWindowSHA() string {
+	store.luaMutex.RLock()
+	defer store.luaMutex.RUnlock()
+	return store.luaSlidingWindowSHA
+}
+
+func parseSlidingWindowState(cmd *libredis.Cmd) (time.Time, int64, int64, bool, int64, error) {
+	result, err := cmd.Result()
+	if err != nil {
+		return time.Time{}, 0, 0, false, 0, errors.Wrap(err, "an error has occurred with redis command")
+	}
+
+	fields, ok := result.([]interface{})
+	if !ok || len(fields) != 5 {
+		return time.Time{}, 0, 0, false, 0, errors.New("five elements in result were expected")
+	}
+
+	values := make([]int64, len(fields))
+	for i := range fields {
+		value, ok := fields[i].(int64)
+		if !ok {
+			return time.Time{}, 0, 0, false, 0, errors.New("type of the window, counters, state and block should be number")
+		}
+		values[i] = value
+	}
+
+	return time.UnixMilli(values[0]), values[1], values[2], values[3] == 1, values[4], nil
+}
@@ -15,31 +15,71 @@ import (
 )
 
 const (
+	// luaBlockPrelude is prepended to every script. KEYS[2] marks the key as blocked and the last
+	// argument is the block duration in milliseconds. start_block blocks the key, if a block duration
+	// is set, and returns the remaining block time.
+	luaBlockPrelude = `
+local block = tonumber(ARGV[#ARGV])
+local blocked = redis.call("pttl", KEYS[2])
+if blocked < 0 then
+	blocked = 0
+end
+local function start_block()
+	if block > 0 then
+		redis.call("set", KEYS[2], 1, "px", block)
+		return block
+	end
+	return 0
+end
+`
+	// luaIncrScript returns the count, its ttl, the remaining block time and whether a weighted
+	// increment, exceeding the limit, was rejected without being counted. A negative increment does
+	// not bring the count below zero.
 	luaIncrScript = `
 local key = KEYS[1]
 local count = tonumber(ARGV[1])
 local ttl = tonumber(ARGV[2])
-local ret = redis.call("incrby", key, ARGV[1])
+local limit = tonumber(ARGV[3])
+if blocked > 0 then
+	return {0, 0, blocked, 0}
+end
+if count > 1 or count < 0 then
+	local current = tonumber(redis.call("get", key) or "0")
+	if count > 1 and current + count > limit then
+		return {current, redis.call("pttl", key), 0, 1}
+	end
+	if current + count < 0 then
+		count = -current
+	end
+	if count == 0 then
+		return {current, redis.call("pttl", key), 0, 0}
+	end
+end
+local ret = redis.call("incrby", key, count)
 if ret == count then
 	if ttl > 0 then
 		redis.call("pexpire", key, ARGV[2])
 	end
-	return {ret, ttl}
+else
+	ttl = redis.call("pttl", key)
+end
+if ret > limit then
+	blocked = start_block()
 end
-ttl = redis.call("pttl", key)
-return {ret, ttl}
+return {ret, ttl, blocked, 0}
 `
 	luaPeekScript = `
 local key = KEYS[1]
 local v = redis.call("get", key)
 if v == false then
-	return {0, 0}
+	return {0, 0, blocked, 0}
 end
 local ttl = redis.call("pttl", key)
-return {tonumber(v), ttl}
+return {tonumber(v), ttl, blocked, 0}
 `
 )
 
+// Client is satisfied by single node, failover (sentinel) and cluster clients.
 type Client interface {
 	Get(ctx context.Context, key string) *libredis.StringCmd
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *libredis.StatusCmd
@@ -48,21 +88,40 @@ type Client interface {
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *libredis.BoolCmd
 	EvalSha(ctx context.Context, sha string, keys []string, args ...interface{}) *libredis.Cmd
 	ScriptLoad(ctx context.Context, script string) *libredis.StringCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *libredis.ScanCmd
+	Pipelined(ctx context.Context, fn func(libredis.Pipeliner) error) ([]libredis.Cmder, error)
 }
 
+var (
+	_ Client = (*libredis.Client)(nil)
+	_ Client = (*libredis.ClusterClient)(nil)
+	_ Client = (libredis.UniversalClient)(nil)
+)
+
 type Store struct {
 	Prefix     string
 	MaxRetry   int
+	MaxLogSize int64
 	client     Client
 	luaMutex   sync.RWMutex
 	luaLoaded  uint32
 	luaIncrSHA string
 	luaPeekSHA string
+
+	luaSlidingWindowSHA string
+	luaGCRASHA          string
+	luaSlidingLogSHA    string
+	luaMultiSHA         string
+
+	luaAcquireSHA string
+	luaRenewSHA   string
+	luaReleaseSHA string
 }
 
 func NewStore(client Client) (limiter.Store, error) {
 	return NewStoreWithOptions(client, limiter.StoreOptions{
-		Prefix: "limiter",
+		Prefix:     limiter.DefaultPrefix,
+		MaxLogSize: limiter.DefaultMaxLogSize,
 		//CleanUpInterval: limiter.DefaultCleanUpInterval,
 		//MaxRetry:        limiter.DefaultMaxRetry,
 	})
@@ -70,11 +129,16 @@ func NewStore(client Client) (limiter.Store, error) {
 
 func NewStoreWithOptions(client Client, options limiter.StoreOptions) (limiter.Store, error) {
 	store := &Store{
-		client: client,
-		Prefix: options.Prefix,
+		client:     client,
+		Prefix:     options.Prefix,
+		MaxLogSize: options.MaxLogSize,
 		// MaxRetry: options.MaxRetry,
 	}
 
+	if store.MaxLogSize <= 0 {
+		store.MaxLogSize = limiter.DefaultMaxLogSize
+	}
+
 	err := store.preloadLuaScripts(context.Background())
 	if err != nil {
 		return nil, err
@@ -84,52 +148,72 @@ func NewStoreWithOptions(client Client, options limiter.StoreOptions) (limiter.S
 }
 
 func (store *Store) Inc(ctx context.Context, key string, count int64, rate limiter.Rate) (limiter.Context, error) {
-	cmd := store.evalSHA(ctx, store.getLuaIncrSHA, []string{store.getCacheKey(key)}, count, rate.Period.Milliseconds())
+	switch rate.Algorithm {
+	case limiter.SlidingWindow:
+		return store.incSlidingWindow(ctx, key, count, rate)
+	case limiter.GCRA:
+		return store.incGCRA(ctx, key, count, rate)
+	case limiter.SlidingLog:
+		return store.incSlidingLog(ctx, key, count, rate)
+	}
+
+	cmd := store.evalSHA(ctx, store.getLuaIncrSHA, store.getKeys(key),
+		count, common.GetWindowTTLMilliseconds(rate, time.Now()), rate.Limit, rate.Block.Milliseconds())
 	return currentContext(cmd, rate)
 }
 
 func (store *Store) Get(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	cmd := store.evalSHA(ctx, store.getLuaIncrSHA, []string{store.getCacheKey(key)}, 1, rate.Period.Milliseconds())
-	return currentContext(cmd, rate)
+	return store.Inc(ctx, key, 1, rate)
 }
 
 func (store *Store) Peek(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	cmd := store.evalSHA(ctx, store.getLuaPeekSHA, []string{store.getCacheKey(key)})
-	count, ttl, err := parseCountAndTTL(cmd)
-	if err != nil {
-		return limiter.Context{}, err
-	}
-
-	now := time.Now()
-	expiration := now.Add(rate.Period)
-	if ttl > 0 {
-		expiration = now.Add(time.Duration(ttl) * time.Millisecond)
+	switch rate.Algorithm {
+	case limiter.SlidingWindow:
+		return store.incSlidingWindow(ctx, key, 0, rate)
+	case limiter.GCRA:
+		return store.incGCRA(ctx, key, 0, rate)
+	case limiter.SlidingLog:
+		return store.incSlidingLog(ctx, key, 0, rate)
 	}
 
-	return common.GetContextFromState(now, rate, expiration, count), nil
+	cmd := store.evalSHA(ctx, store.getLuaPeekSHA, store.getKeys(key), rate.Block.Milliseconds())
+	return currentContext(cmd, rate)
 }
 
 func (store *Store) Reset(ctx context.Context, key string, rate limiter.Rate) (limiter.Context, error) {
-	_, err := store.client.Del(ctx, store.getCacheKey(key)).Result()
+	keys := append(store.getKeys(key), store.getMultiKey(key, rate.Period))
+	_, err := store.client.Del(ctx, keys...).Result()
 	if err != nil {
 		return limiter.Context{}, err
 	}
 
 	count := int64(0)
 	now := time.Now()
-	expiration := now.Add(rate.Period)
+	expiration := now.Add(rate.GetWindowTTL(now))
 
 	return common.GetContextFromState(now, rate, expiration, count), nil
 }
 
+// getCacheKey returns the key storing the state of given key. The key is wrapped in a hash tag, so
+// that the keys derived from it are stored in the same slot of a cluster.
 func (store *Store) getCacheKey(key string) string {
 	buffer := strings.Builder{}
 	buffer.WriteString(store.Prefix)
-	buffer.WriteString(":")
+	buffer.WriteString(":{")
 	buffer.WriteString(key)
+	buffer.WriteString("}")
 	return buffer.String()
 }
 
+func (store *Store) getBlockKey(key string) string {
+	return store.getCacheKey(key) + ":blocked"
+}
+
+// getKeys returns the keys used by lua scripts: the state of the key and its block marker.
+func (store *Store) getKeys(key string) []string {
+	return []string{store.getCacheKey(key), store.getBlockKey(key)}
+}
+
 func (store *Store) preloadLuaScripts(ctx context.Context) error {
 	// Verify if we need to load lua scripts.
 	// Inspired by sync.Once.
@@ -140,6 +224,8 @@ func (store *Store) preloadLuaScripts(ctx context.Context) error {
 }
 
 func (store *Store) reloadLuaScripts(ctx context.Context) error {
+	// A node may lack the scripts after a restart, a failover or a resharding. Loading them again
+	// sends them to every node of a cluster.
 	// Reset lua scripts loaded state.
 	// Inspired by sync.Once.
 	atomic.StoreUint32(&store.luaLoaded, 0)
@@ -155,18 +241,61 @@ func (store *Store) loadLuaScripts(ctx context.Context) error {
 		return nil
 	}
 
-	luaIncrSHA, err := store.client.ScriptLoad(ctx, luaIncrScript).Result()
+	luaIncrSHA, err := store.client.ScriptLoad(ctx, luaBlockPrelude+luaIncrScript).Result()
 	if err != nil {
 		return errors.Wrap(err, `failed to load "incr" lua script`)
 	}
 
-	luaPeekSHA, err := store.client.ScriptLoad(ctx, luaPeekScript).Result()
+	luaPeekSHA, err := store.client.ScriptLoad(ctx, luaBlockPrelude+luaPeekScript).Result()
 	if err != nil {
 		return errors.Wrap(err, `failed to load "peek" lua script`)
 	}
 
+	luaSlidingWindowSHA, err := store.client.ScriptLoad(ctx, luaBlockPrelude+luaSlidingWindowScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "sliding window" lua script`)
+	}
+
+	luaGCRASHA, err := store.client.ScriptLoad(ctx, luaBlockPrelude+luaGCRAScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "gcra" lua script`)
+	}
+
+	luaSlidingLogSHA, err := store.client.ScriptLoad(ctx, luaBlockPrelude+luaSlidingLogScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "sliding log" lua script`)
+	}
+
+	// The multiple rates and the concurrency scripts don't use blocks.
+	luaMultiSHA, err := store.client.ScriptLoad(ctx, luaMultiScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "multi" lua script`)
+	}
+
+	luaAcquireSHA, err := store.client.ScriptLoad(ctx, luaAcquireScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "acquire" lua script`)
+	}
+
+	luaRenewSHA, err := store.client.ScriptLoad(ctx, luaRenewScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "renew" lua script`)
+	}
+
+	luaReleaseSHA, err := store.client.ScriptLoad(ctx, luaReleaseScript).Result()
+	if err != nil {
+		return errors.Wrap(err, `failed to load "release" lua script`)
+	}
+
 	store.luaIncrSHA = luaIncrSHA
 	store.luaPeekSHA = luaPeekSHA
+	store.luaSlidingWindowSHA = luaSlidingWindowSHA
+	store.luaGCRASHA = luaGCRASHA
+	store.luaSlidingLogSHA = luaSlidingLogSHA
+	store.luaMultiSHA = luaMultiSHA
+	store.luaAcquireSHA = luaAcquireSHA
+	store.luaRenewSHA = luaRenewSHA
+	store.luaReleaseSHA = luaReleaseSHA
 
 	atomic.StoreUint32(&store.luaLoaded, 1)
 
@@ -208,37 +337,51 @@ func isLuaScriptGone(err error) bool {
 	return strings.HasPrefix(err.Error(), "NOSCRIPT")
 }
 
-func parseCountAndTTL(cmd *libredis.Cmd) (int64, int64, error) {
+func parseCountAndTTL(cmd *libredis.Cmd) (int64, int64, int64, bool, error) {
 	result, err := cmd.Result()
 	if err != nil {
-		return 0, 0, errors.Wrap(err, "an error has occurred with redis command")
+		return 0, 0, 0, false, errors.Wrap(err, "an error has occurred with redis command")
 	}
 
 	fields, ok := result.([]interface{})
-	if !ok || len(fields) != 2 {
-		return 0, 0, errors.New("two elements in result were expected")
+	if !ok || len(fields) != 4 {
+		return 0, 0, 0, false, errors.New("four elements in result were expected")
 	}
 
 	count, ok1 := fields[0].(int64)
 	ttl, ok2 := fields[1].(int64)
-	if !ok1 || !ok2 {
-		return 0, 0, errors.New("type of the count and/or ttl should be number")
+	blocked, ok3 := fields[2].(int64)
+	rejected, ok4 := fields[3].(int64)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return 0, 0, 0, false, errors.New("type of the count, ttl, block and/or rejection should be number")
 	}
 
-	return count, ttl, nil
+	return count, ttl, blocked, rejected == 1, nil
 }
 
 func currentContext(cmd *libredis.Cmd, rate limiter.Rate) (limiter.Context, error) {
-	count, ttl, err := parseCountAndTTL(cmd)
+	count, ttl, blocked, rejected, err := parseCountAndTTL(cmd)
 	if err != nil {
 		return limiter.Context{}, err
 	}
 
 	now := time.Now()
-	expiration := now.Add(rate.Period)
-	if ttl > 0 {
+	if blocked > 0 {
+		return common.GetBlockedContextFromState(rate.Limit, getBlockedUntil(now, blocked)), nil
+	}
+
+	// Windows aligned to the calendar end at the boundary, which the ttl in milliseconds would round down.
+	expiration := now.Add(rate.GetWindowTTL(now))
+	if ttl > 0 && rate.Calendar == "" {
 		expiration = now.Add(time.Duration(ttl) * time.Millisecond)
 	}
 
-	return common.GetContextFromState(now, rate, expiration, count), nil
+	lctx := common.GetContextFromState(now, rate, expiration, count)
+	lctx.Reached = lctx.Reached || rejected
+	return lctx, nil
+}
+
+// getBlockedUntil returns the end of a block, given its remaining time in milliseconds.
+func getBlockedUntil(now time.Time, blocked int64) time.Time {
+	return now.Add(time.Duration(blocked) * time.Millisecond)
 }
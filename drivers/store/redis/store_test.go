@@ -41,6 +41,319 @@ func TestRedisStoreSequentialAccess(t *testing.T) {
 	tests.TestStoreSequentialAccess(t, store)
 }
 
+func TestRedisStoreSlidingWindowAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:sliding-window-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreSlidingWindowAccess(t, store)
+}
+
+// TestRedisStoreSlidingWindowClockSkew checks that a window started by an instance whose clock is ahead
+// is kept as the current window, instead of resetting the counters of every instance.
+func TestRedisStoreSlidingWindowClockSkew(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:sliding-window-skew-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	rate := limiter.Rate{
+		Limit:     10,
+		Period:    1 * time.Minute,
+		Algorithm: limiter.SlidingWindow,
+	}
+
+	// Another instance, one period ahead, counted 8 requests in its current window.
+	ahead := time.Now().Add(rate.Period).Truncate(rate.Period)
+	err = client.HSet(ctx, "limiter:redis:sliding-window-skew-test:{foo}",
+		"window", ahead.UnixMilli(), "current", 8, "previous", 0).Err()
+	is.NoError(err)
+
+	lctx, err := store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.False(lctx.Reached)
+	is.Equal(int64(1), lctx.Remaining)
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.False(lctx.Reached)
+	is.Equal(int64(0), lctx.Remaining)
+
+	lctx, err = store.Get(ctx, "foo", rate)
+	is.NoError(err)
+	is.True(lctx.Reached)
+}
+
+func TestRedisStoreGCRAAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:gcra-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreGCRAAccess(t, store)
+}
+
+func TestRedisStoreSlidingLogAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:sliding-log-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreSlidingLogAccess(t, store)
+}
+
+func TestRedisStoreBlockAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:block-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreBlockAccess(t, store)
+}
+
+func TestRedisStoreWeightedAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:weighted-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreWeightedAccess(t, store)
+}
+
+func TestRedisStoreGrantAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:grant-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreGrantAccess(t, store)
+}
+
+func TestRedisStoreMultiRateAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:multi-rate-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreMultiRateAccess(t, store)
+}
+
+func TestRedisStoreCalendarAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:calendar-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestStoreCalendarAccess(t, store)
+}
+
+func TestRedisStoreScan(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:scan-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	blocking := limiter.NewLimiter(store, limiter.Rate{Limit: 3, Period: time.Minute, Block: time.Hour})
+	for i := 0; i < 5; i++ {
+		_, err = blocking.Get(ctx, "ip:192.168.0.1")
+		is.NoError(err)
+	}
+
+	sliding := limiter.NewLimiter(store, limiter.Rate{Limit: 10, Period: time.Minute}, limiter.WithAlgorithm(limiter.SlidingLog))
+	_, err = sliding.Inc(ctx, "ip:192.168.0.2", 2)
+	is.NoError(err)
+
+	multi := limiter.NewLimiter(store, limiter.Rate{Limit: 10, Period: time.Second}, limiter.WithRates(limiter.Rate{
+		Limit:  100,
+		Period: time.Hour,
+	}))
+	_, err = multi.Inc(ctx, "token:abc", 6)
+	is.NoError(err)
+
+	gcra := limiter.NewLimiter(store, limiter.Rate{Limit: 10, Period: time.Minute}, limiter.WithAlgorithm(limiter.GCRA))
+	_, err = gcra.Inc(ctx, "token:def", 3)
+	is.NoError(err)
+
+	// Check that every state of a key is merged.
+	states, err := limiter.ScanAll(ctx, store.(limiter.ScanStore), nil)
+	is.NoError(err)
+	is.Len(states, 4)
+
+	// Check that the usage of a GCRA key is derived from its arrival time.
+	for _, state := range states {
+		if state.Key == "token:def" {
+			is.Equal(int64(3), state.Count)
+		}
+	}
+
+	top, err := limiter.TopKeys(ctx, store.(limiter.ScanStore), 2)
+	is.NoError(err)
+	is.Len(top, 2)
+	is.Equal("token:abc", top[0].Key)
+	is.Equal(int64(6), top[0].Count)
+	is.Equal("ip:192.168.0.1", top[1].Key)
+	is.Equal(int64(4), top[1].Count)
+
+	blocked, err := limiter.BlockedKeys(ctx, store.(limiter.ScanStore))
+	is.NoError(err)
+	is.Len(blocked, 1)
+	is.Equal("ip:192.168.0.1", blocked[0].Key)
+	is.InDelta(time.Hour, blocked[0].BlockedFor, float64(time.Minute))
+}
+
+func TestRedisConcurrencyStoreAccess(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	setup(ctx, t)
+	defer func() {
+		tearDown(t)
+	}()
+
+	client, err := newRedisClient(redisURL)
+	is.NoError(err)
+	is.NotNil(client)
+
+	store, err := redis.NewConcurrencyStoreWithOptions(client, limiter.StoreOptions{
+		Prefix: "limiter:redis:concurrency-test",
+	})
+	is.NoError(err)
+	is.NotNil(store)
+
+	tests.TestConcurrencyStoreAccess(t, store)
+}
+
 func TestRedisStoreConcurrentAccess(t *testing.T) {
 	is := require.New(t)
 	ctx := context.Background()
@@ -113,6 +113,622 @@ func TestStoreSequentialAccess(t *testing.T, store limiter.Store) {
 	}
 }
 
+func TestStoreSlidingWindowAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  3,
+		Period: 1 * time.Minute,
+	}, limiter.WithAlgorithm(limiter.SlidingWindow))
+
+	// Check that only allowed requests are counted.
+	{
+		for i := 1; i <= 6; i++ {
+			lctx, err := limiter.Get(ctx, "foo")
+			is.NoError(err)
+			is.NotZero(lctx)
+			is.Equal(int64(3), lctx.Limit)
+
+			if i <= 3 {
+				is.Equal(int64(3-i), lctx.Remaining)
+				is.True((lctx.Reset - time.Now().Unix()) <= 120)
+				is.False(lctx.Reached)
+			} else {
+				is.Equal(int64(0), lctx.Remaining)
+				is.True((lctx.Reset - time.Now().Unix()) <= 120)
+				is.True(lctx.Reached)
+			}
+		}
+
+		lctx, err := limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(0), lctx.Remaining)
+		is.True(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", -1)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", 2)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.True(lctx.Reached)
+	}
+
+	// Check counter reset.
+	{
+		lctx, err := limiter.Reset(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Remaining)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Remaining)
+		is.False(lctx.Reached)
+	}
+
+	// Check that the previous window is weighted by its overlap.
+	{
+		limiter.Rate.Period = 500 * time.Millisecond
+
+		for i := 1; i <= 3; i++ {
+			lctx, err := limiter.Get(ctx, "bar")
+			is.NoError(err)
+			is.False(lctx.Reached)
+		}
+
+		// Wait for the start of the next window.
+		for {
+			lctx, err := limiter.Peek(ctx, "bar")
+			is.NoError(err)
+			if !lctx.Reached {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		lctx, err := limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.True(lctx.Reached)
+	}
+}
+
+func TestStoreGCRAAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  1,
+		Period: 1 * time.Minute,
+		Burst:  3,
+	}, limiter.WithAlgorithm(limiter.GCRA))
+
+	// Check burst consumption.
+	{
+		lctx, err := limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Limit)
+		is.Equal(int64(3), lctx.Remaining)
+		is.False(lctx.Reached)
+
+		for i := 1; i <= 5; i++ {
+			lctx, err := limiter.Get(ctx, "foo")
+			is.NoError(err)
+			is.NotZero(lctx)
+			is.Equal(int64(3), lctx.Limit)
+			is.True((lctx.Reset - time.Now().Unix()) <= 61)
+
+			if i <= 3 {
+				is.Equal(int64(3-i), lctx.Remaining)
+				is.False(lctx.Reached)
+			} else {
+				is.Equal(int64(0), lctx.Remaining)
+				is.True((lctx.Reset - time.Now().Unix()) >= 59)
+				is.True(lctx.Reached)
+			}
+		}
+
+		lctx, err = limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(0), lctx.Remaining)
+		is.True(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", -1)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", 2)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.True((lctx.Reset - time.Now().Unix()) >= 59)
+		is.True(lctx.Reached)
+	}
+
+	// Check counter reset.
+	{
+		lctx, err := limiter.Reset(ctx, "foo")
+		is.NoError(err)
+		is.NotZero(lctx)
+
+		lctx, err = limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Remaining)
+		is.False(lctx.Reached)
+	}
+
+	// Check token refill.
+	{
+		limiter.Rate.Limit = 10
+		limiter.Rate.Period = 1 * time.Second
+		limiter.Rate.Burst = 1
+
+		lctx, err := limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.True(lctx.Reached)
+
+		time.Sleep(150 * time.Millisecond)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.False(lctx.Reached)
+	}
+}
+
+func TestStoreSlidingLogAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	maxLogSize := int64(limiter.DefaultMaxLogSize)
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  3,
+		Period: 1 * time.Minute,
+	}, limiter.WithAlgorithm(limiter.SlidingLog))
+
+	// Check that only allowed requests are logged.
+	{
+		for i := 1; i <= 5; i++ {
+			lctx, err := limiter.Get(ctx, "foo")
+			is.NoError(err)
+			is.NotZero(lctx)
+			is.Equal(int64(3), lctx.Limit)
+			is.True((lctx.Reset - time.Now().Unix()) <= 60)
+
+			if i <= 3 {
+				is.Equal(int64(3-i), lctx.Remaining)
+				is.False(lctx.Reached)
+			} else {
+				is.Equal(int64(0), lctx.Remaining)
+				is.True(lctx.Reached)
+			}
+		}
+
+		lctx, err := limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(0), lctx.Remaining)
+		is.True(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", -1)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Inc(ctx, "foo", 2)
+		is.NoError(err)
+		is.Equal(int64(1), lctx.Remaining)
+		is.True(lctx.Reached)
+	}
+
+	// Check counter reset.
+	{
+		lctx, err := limiter.Reset(ctx, "foo")
+		is.NoError(err)
+		is.NotZero(lctx)
+
+		lctx, err = limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Remaining)
+		is.False(lctx.Reached)
+	}
+
+	// Check that timestamps leave the sliding period.
+	{
+		limiter.Rate.Period = 200 * time.Millisecond
+
+		lctx, err := limiter.Inc(ctx, "bar", 3)
+		is.NoError(err)
+		is.False(lctx.Reached)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.True(lctx.Reached)
+
+		time.Sleep(250 * time.Millisecond)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.Equal(int64(2), lctx.Remaining)
+		is.False(lctx.Reached)
+	}
+
+	// Check that the limit cannot exceed the maximum log size.
+	{
+		limiter.Rate.Limit = maxLogSize + 1
+
+		_, err := limiter.Get(ctx, "baz")
+		is.Error(err)
+	}
+}
+
+func TestStoreBlockAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	algorithms := []limiter.Algorithm{
+		limiter.FixedWindow,
+		limiter.SlidingWindow,
+		limiter.GCRA,
+		limiter.SlidingLog,
+	}
+
+	for _, algorithm := range algorithms {
+		key := "foo-" + string(algorithm)
+
+		limiter := limiter.NewLimiter(store, limiter.Rate{
+			Limit:  2,
+			Period: 200 * time.Millisecond,
+		}, limiter.WithAlgorithm(algorithm), limiter.WithBlockDuration(1*time.Minute))
+
+		// Check that exceeding the limit blocks the key.
+		{
+			for i := 1; i <= 2; i++ {
+				lctx, err := limiter.Get(ctx, key)
+				is.NoError(err)
+				is.False(lctx.Reached, algorithm)
+				is.Zero(lctx.BlockedUntil, algorithm)
+			}
+
+			lctx, err := limiter.Get(ctx, key)
+			is.NoError(err)
+			is.True(lctx.Reached, algorithm)
+			is.Equal(int64(0), lctx.Remaining, algorithm)
+			is.True((lctx.BlockedUntil-time.Now().Unix()) >= 59, algorithm)
+			is.Equal(lctx.BlockedUntil, lctx.Reset, algorithm)
+		}
+
+		// Check that the block outlives the window.
+		{
+			time.Sleep(250 * time.Millisecond)
+
+			lctx, err := limiter.Get(ctx, key)
+			is.NoError(err)
+			is.True(lctx.Reached, algorithm)
+			is.NotZero(lctx.BlockedUntil, algorithm)
+
+			lctx, err = limiter.Peek(ctx, key)
+			is.NoError(err)
+			is.True(lctx.Reached, algorithm)
+			is.NotZero(lctx.BlockedUntil, algorithm)
+		}
+
+		// Check that reset removes the block.
+		{
+			_, err := limiter.Reset(ctx, key)
+			is.NoError(err)
+
+			lctx, err := limiter.Get(ctx, key)
+			is.NoError(err)
+			is.False(lctx.Reached, algorithm)
+			is.Zero(lctx.BlockedUntil, algorithm)
+		}
+	}
+}
+
+func TestStoreWeightedAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  10,
+		Period: 1 * time.Minute,
+	}, limiter.WithBlockDuration(1*time.Minute))
+
+	for _, remaining := range []int64{6, 2} {
+		lctx, err := limiter.Inc(ctx, "foo", 4)
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(remaining, lctx.Remaining)
+	}
+
+	// Check that a weighted request exceeding the limit is neither counted nor blocks the key.
+	{
+		lctx, err := limiter.Inc(ctx, "foo", 4)
+		is.NoError(err)
+		is.True(lctx.Reached)
+		is.Equal(int64(2), lctx.Remaining)
+		is.Zero(lctx.BlockedUntil)
+
+		lctx, err = limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(int64(2), lctx.Remaining)
+	}
+
+	// Check that the remaining quota is kept for cheaper requests.
+	for _, remaining := range []int64{1, 0} {
+		lctx, err := limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(remaining, lctx.Remaining)
+	}
+
+	lctx, err := limiter.Get(ctx, "foo")
+	is.NoError(err)
+	is.True(lctx.Reached)
+	is.NotZero(lctx.BlockedUntil)
+
+	// Check that a weighted request exceeding the limit of a new key is not counted.
+	lctx, err = limiter.Inc(ctx, "bar", 11)
+	is.NoError(err)
+	is.True(lctx.Reached)
+
+	lctx, err = limiter.Peek(ctx, "bar")
+	is.NoError(err)
+	is.Equal(int64(10), lctx.Remaining)
+}
+
+func TestStoreGrantAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	rate := limiter.Rate{
+		Limit:  5,
+		Period: 1 * time.Minute,
+	}
+
+	// Check that a grant does not bring the counter of a fixed window below zero.
+	{
+		limiter := limiter.NewLimiter(store, rate)
+
+		lctx, err := limiter.Inc(ctx, "foo", 2)
+		is.NoError(err)
+		is.Equal(int64(3), lctx.Remaining)
+
+		lctx, err = limiter.Inc(ctx, "foo", -4)
+		is.NoError(err)
+		is.Equal(int64(5), lctx.Remaining)
+
+		lctx, err = limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.Equal(int64(4), lctx.Remaining)
+
+		lctx, err = limiter.Inc(ctx, "bar", -3)
+		is.NoError(err)
+		is.Equal(int64(5), lctx.Remaining)
+
+		lctx, err = limiter.Get(ctx, "bar")
+		is.NoError(err)
+		is.Equal(int64(4), lctx.Remaining)
+	}
+
+	// Check that a grant does not bring the current window of a sliding window below zero.
+	{
+		limiter := limiter.NewLimiter(store, rate, limiter.WithAlgorithm(limiter.SlidingWindow))
+
+		_, err := limiter.Get(ctx, "baz")
+		is.NoError(err)
+
+		lctx, err := limiter.Inc(ctx, "baz", -3)
+		is.NoError(err)
+		is.Equal(int64(5), lctx.Remaining)
+
+		for i := 1; i <= 6; i++ {
+			lctx, err = limiter.Get(ctx, "baz")
+			is.NoError(err)
+			is.Equal(i > 5, lctx.Reached)
+		}
+	}
+
+	// Check that a grant does not bring the counter of any rate below zero.
+	{
+		limiter := limiter.NewLimiter(store, rate, limiter.WithRates(limiter.Rate{
+			Limit:  10,
+			Period: 1 * time.Hour,
+		}))
+
+		for i := 1; i <= 2; i++ {
+			_, err := limiter.Get(ctx, "qux")
+			is.NoError(err)
+		}
+
+		lctx, err := limiter.Inc(ctx, "qux", -4)
+		is.NoError(err)
+		is.Equal(int64(5), lctx.Remaining)
+
+		for i := 1; i <= 6; i++ {
+			lctx, err = limiter.Get(ctx, "qux")
+			is.NoError(err)
+			is.Equal(i > 5, lctx.Reached)
+		}
+	}
+}
+
+func TestStoreMultiRateAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	limiter := limiter.NewLimiter(store, limiter.Rate{
+		Limit:  3,
+		Period: 200 * time.Millisecond,
+	}, limiter.WithRates(limiter.Rate{
+		Limit:  5,
+		Period: 1 * time.Minute,
+	}))
+
+	// Check that the most restrictive rate applies.
+	{
+		for i := 1; i <= 3; i++ {
+			lctx, err := limiter.Get(ctx, "foo")
+			is.NoError(err)
+			is.False(lctx.Reached)
+			is.Equal(int64(3), lctx.Limit)
+			is.Equal(int64(3-i), lctx.Remaining)
+		}
+
+		lctx, err := limiter.Peek(ctx, "foo")
+		is.NoError(err)
+		is.True(lctx.Reached)
+		is.Equal(int64(3), lctx.Limit)
+
+		lctx, err = limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.True(lctx.Reached)
+		is.Equal(int64(3), lctx.Limit)
+		is.Equal(int64(0), lctx.Remaining)
+	}
+
+	// Check that a rejected request is not counted by the other rates.
+	{
+		time.Sleep(250 * time.Millisecond)
+
+		lctx, err := limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(int64(5), lctx.Limit)
+		is.Equal(int64(1), lctx.Remaining)
+
+		lctx, err = limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(int64(0), lctx.Remaining)
+
+		lctx, err = limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.True(lctx.Reached)
+		is.Equal(int64(5), lctx.Limit)
+		is.True((lctx.Reset - time.Now().Unix()) >= 55)
+	}
+
+	// Check that reset applies to every rate.
+	{
+		_, err := limiter.Reset(ctx, "foo")
+		is.NoError(err)
+
+		lctx, err := limiter.Get(ctx, "foo")
+		is.NoError(err)
+		is.False(lctx.Reached)
+		is.Equal(int64(3), lctx.Limit)
+		is.Equal(int64(2), lctx.Remaining)
+	}
+
+	// Check that other algorithms are rejected.
+	{
+		limiter.Rate.Algorithm = "gcra"
+		_, err := limiter.Get(ctx, "bar")
+		is.Error(err)
+	}
+}
+
+func TestStoreCalendarAccess(t *testing.T, store limiter.Store) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	location, err := time.LoadLocation("America/Sao_Paulo")
+	is.NoError(err)
+
+	rates := []limiter.Rate{
+		limiter.NewCalendarRate(2, limiter.CalendarHour, nil),
+		limiter.NewCalendarRate(2, limiter.CalendarDay, location),
+		limiter.NewCalendarRate(2, limiter.CalendarMonth, time.UTC),
+	}
+
+	// Check that every key resets at the next boundary, regardless of its first request.
+	for _, rate := range rates {
+		limiter := limiter.NewLimiter(store, rate)
+		key := "calendar-" + string(rate.Calendar)
+
+		for i := 1; i <= 3; i++ {
+			lctx, err := limiter.Get(ctx, key)
+			is.NoError(err)
+			is.Equal(i > 2, lctx.Reached)
+			is.Equal(rate.Calendar.Next(time.Now(), rate.Location).Unix(), lctx.Reset)
+		}
+
+		lctx, err := limiter.Reset(ctx, key)
+		is.NoError(err)
+		is.Equal(int64(2), lctx.Remaining)
+		is.Equal(rate.Calendar.Next(time.Now(), rate.Location).Unix(), lctx.Reset)
+	}
+}
+
+func TestConcurrencyStoreAccess(t *testing.T, store limiter.ConcurrencyStore) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	limiter := limiter.NewConcurrencyLimiter(store, 2, 200*time.Millisecond)
+
+	// Check that leases are limited.
+	first, err := limiter.Acquire(ctx, "foo")
+	is.NoError(err)
+	is.True(first.Acquired)
+	is.Equal(int64(1), first.InFlight)
+	is.Equal(int64(2), first.Limit)
+
+	second, err := limiter.Acquire(ctx, "foo")
+	is.NoError(err)
+	is.True(second.Acquired)
+	is.Equal(int64(2), second.InFlight)
+	is.NotEqual(first.ID, second.ID)
+
+	third, err := limiter.Acquire(ctx, "foo")
+	is.NoError(err)
+	is.False(third.Acquired)
+	is.Equal(int64(2), third.InFlight)
+
+	other, err := limiter.Acquire(ctx, "bar")
+	is.NoError(err)
+	is.True(other.Acquired)
+
+	// Check that release frees a slot.
+	is.NoError(limiter.Release(ctx, first))
+
+	third, err = limiter.Acquire(ctx, "foo")
+	is.NoError(err)
+	is.True(third.Acquired)
+	is.Equal(int64(2), third.InFlight)
+
+	// Check that renewed leases are kept, and others expire.
+	time.Sleep(120 * time.Millisecond)
+
+	second, err = limiter.Renew(ctx, second)
+	is.NoError(err)
+	is.True(second.Acquired)
+
+	time.Sleep(120 * time.Millisecond)
+
+	third, err = limiter.Renew(ctx, third)
+	is.NoError(err)
+	is.False(third.Acquired)
+	is.Equal(int64(1), third.InFlight)
+
+	lease, err := limiter.Acquire(ctx, "foo")
+	is.NoError(err)
+	is.True(lease.Acquired)
+	is.Equal(int64(2), lease.InFlight)
+}
+
 func TestStoreConcurrentAccess(t *testing.T, store limiter.Store) {
 	is := require.New(t)
 	ctx := context.Background()
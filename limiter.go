@@ -1,38 +1,95 @@
 package limiter
 
-import "context"
+import (
+	"context"
+	"net"
+)
 
 type Context struct {
 	Limit     int64
 	Remaining int64
 	Reset     int64
 	Reached   bool
+	// BlockedUntil is the Unix time until which the key is blocked after exceeding the limit, or zero.
+	BlockedUntil int64
+	// RateIndex is the index, in the rates of the limiter, of the rate reported by the context: zero
+	// for Rate, i+1 for Rates[i].
+	RateIndex int
 }
 
 type Limiter struct {
 	Store Store
 	Rate  Rate
+	// Rates are enforced together with Rate: a request is only counted if it is allowed by all of them.
+	Rates []Rate
+	// TrustedProxies are the networks whose forwarding headers are honored to resolve the client IP.
+	TrustedProxies []*net.IPNet
+	// TrustedHeader is the only forwarding header honored, such as X-Forwarded-For or Forwarded.
+	TrustedHeader string
+	// IPv4Mask and IPv6Mask mask client IPs, so that keys are derived from their network.
+	IPv4Mask net.IPMask
+	IPv6Mask net.IPMask
 }
 
-func NewLimiter(store Store, rate Rate) *Limiter {
-	return &Limiter{
-		Store: store,
-		Rate:  rate,
+func NewLimiter(store Store, rate Rate, options ...Option) *Limiter {
+	limiter := &Limiter{
+		Store:         store,
+		Rate:          rate,
+		TrustedHeader: DefaultTrustedHeader,
+		IPv4Mask:      DefaultIPv4Mask,
+		IPv6Mask:      DefaultIPv6Mask,
 	}
+
+	for _, option := range options {
+		option.apply(limiter)
+	}
+
+	return limiter
 }
 
 func (l *Limiter) Get(ctx context.Context, key string) (Context, error) {
-	return l.Store.Get(ctx, key, l.Rate)
+	return l.Inc(ctx, key, 1)
 }
 
 func (l *Limiter) Peek(ctx context.Context, key string) (Context, error) {
-	return l.Store.Peek(ctx, key, l.Rate)
+	if len(l.Rates) == 0 {
+		return l.Store.Peek(ctx, key, l.Rate)
+	}
+
+	store, ok := l.Store.(MultiStore)
+	if !ok {
+		return Context{}, ErrMultipleRatesUnsupported
+	}
+	return store.PeekMulti(ctx, key, l.GetRates())
 }
 
 func (l *Limiter) Reset(ctx context.Context, key string) (Context, error) {
-	return l.Store.Reset(ctx, key, l.Rate)
+	lctx, err := l.Store.Reset(ctx, key, l.Rate)
+	for _, rate := range l.Rates {
+		if err != nil {
+			break
+		}
+		_, err = l.Store.Reset(ctx, key, rate)
+	}
+	return lctx, err
 }
 
 func (l *Limiter) Inc(ctx context.Context, key string, count int64) (Context, error) {
-	return l.Store.Inc(ctx, key, count, l.Rate)
+	if len(l.Rates) == 0 {
+		if count == 1 {
+			return l.Store.Get(ctx, key, l.Rate)
+		}
+		return l.Store.Inc(ctx, key, count, l.Rate)
+	}
+
+	store, ok := l.Store.(MultiStore)
+	if !ok {
+		return Context{}, ErrMultipleRatesUnsupported
+	}
+	return store.IncMulti(ctx, key, count, l.GetRates())
+}
+
+// GetRates returns every rate enforced by the limiter.
+func (l *Limiter) GetRates() []Rate {
+	return append([]Rate{l.Rate}, l.Rates...)
 }
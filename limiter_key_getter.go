@@ -10,8 +10,9 @@ func (limiter *Limiter) GetToken(r *http.Request) string {
 	return r.Header.Get("API_KEY")
 }
 
+// GetIP returns the IP address of the client, masked by the IPv4 or IPv6 mask of the limiter.
 func (limiter *Limiter) GetIP(r *http.Request) net.IP {
-	return GetIP(r)
+	return MaskIP(GetClientIP(r, limiter.TrustedProxies, limiter.TrustedHeader), limiter.IPv4Mask, limiter.IPv6Mask)
 }
 
 func (limiter *Limiter) CheckIfKeyIsIPAddress(ip string) bool {
@@ -0,0 +1,65 @@
+package limiter
+
+import (
+	"net"
+	"time"
+)
+
+type Option interface {
+	apply(*Limiter)
+}
+
+type option func(*Limiter)
+
+func (o option) apply(l *Limiter) {
+	o(l)
+}
+
+func WithAlgorithm(algorithm Algorithm) Option {
+	return option(func(l *Limiter) {
+		l.Rate.Algorithm = algorithm
+	})
+}
+
+// WithRates enforces the rates together with the rate of the limiter, such as 10 requests per
+// second and 10000 per day. The store must implement MultiStore.
+func WithRates(rates ...Rate) Option {
+	return option(func(l *Limiter) {
+		l.Rates = append(l.Rates, rates...)
+	})
+}
+
+func WithBlockDuration(duration time.Duration) Option {
+	return option(func(l *Limiter) {
+		l.Rate.Block = duration
+	})
+}
+
+// WithTrustedProxies honors the forwarding headers of requests coming from the given networks.
+func WithTrustedProxies(networks ...*net.IPNet) Option {
+	return option(func(l *Limiter) {
+		l.TrustedProxies = networks
+	})
+}
+
+// WithTrustedHeader honors the given forwarding header of trusted proxies, instead of X-Forwarded-For.
+// It should be the header set by the proxies: the others are passed through as written by the client.
+func WithTrustedHeader(header string) Option {
+	return option(func(l *Limiter) {
+		l.TrustedHeader = header
+	})
+}
+
+// WithIPv4Prefix masks IPv4 client addresses to the given prefix length, e.g. 24.
+func WithIPv4Prefix(bits int) Option {
+	return option(func(l *Limiter) {
+		l.IPv4Mask = net.CIDRMask(bits, 8*net.IPv4len)
+	})
+}
+
+// WithIPv6Prefix masks IPv6 client addresses to the given prefix length, e.g. 64.
+func WithIPv6Prefix(bits int) Option {
+	return option(func(l *Limiter) {
+		l.IPv6Mask = net.CIDRMask(bits, 8*net.IPv6len)
+	})
+}
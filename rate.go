@@ -1,10 +1,60 @@
 package limiter
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type Algorithm string
+
+const (
+	// FixedWindow counts requests in windows starting at the first request of a key.
+	FixedWindow Algorithm = "fixed-window"
+	// SlidingWindow weights the counter of the previous window by how much of it still overlaps
+	// the sliding period, preventing bursts of up to twice the limit across window boundaries.
+	SlidingWindow Algorithm = "sliding-window"
+	// GCRA is the generic cell rate algorithm, equivalent to a token bucket refilled with Limit tokens
+	// per Period and holding up to Burst tokens.
+	GCRA Algorithm = "gcra"
+	// SlidingLog records the timestamp of every request, giving an exact count over the sliding period
+	// at the cost of memory proportional to the limit.
+	SlidingLog Algorithm = "sliding-log"
+)
+
+// IsValid reports whether the algorithm is known. The empty algorithm selects the fixed window.
+func (algorithm Algorithm) IsValid() bool {
+	switch algorithm {
+	case "", FixedWindow, SlidingWindow, GCRA, SlidingLog:
+		return true
+	}
+	return false
+}
+
+// Calendar is a calendar unit the windows of a rate can be aligned to.
+type Calendar string
+
+const (
+	CalendarHour  Calendar = "hour"
+	CalendarDay   Calendar = "day"
+	CalendarMonth Calendar = "month"
+)
 
 type Rate struct {
-	Period time.Duration
-	Limit  int64
+	Period    time.Duration
+	Limit     int64
+	Burst     int64
+	Algorithm Algorithm
+	// Block is how long a key is rejected once it exceeded the limit, regardless of new windows.
+	Block time.Duration
+	// Calendar aligns the windows of the fixed window algorithm to the boundaries of a calendar unit
+	// in Location, UTC by default, so the counters of every key reset at the same instant.
+	Calendar Calendar
+	Location *time.Location
 }
 
 func NewRate(limit int64, period int) Rate {
@@ -15,3 +65,122 @@ func NewRate(limit int64, period int) Rate {
 
 	return rate
 }
+
+// rateUnits are the units of the periods of ParseRate, from the largest to the smallest.
+var rateUnits = []struct {
+	name     string
+	duration time.Duration
+}{
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+	{"ms", time.Millisecond},
+}
+
+// ParseRate parses a rate formatted as the limit, followed by "/" or "-" and the period: a unit,
+// optionally preceded by a multiplier. Units are "ms", "s", "m", "h" and "d", in any case,
+// e.g. "10/s", "100-M", "5000/h", "1000-D" or "500/30s".
+func ParseRate(value string) (Rate, error) {
+	separator := strings.IndexAny(value, "/-")
+	if separator < 0 {
+		return Rate{}, errors.Errorf("invalid rate %q: limit and period should be separated by / or -", value)
+	}
+
+	limit, err := strconv.ParseInt(strings.TrimSpace(value[:separator]), 10, 64)
+	if err != nil || limit <= 0 {
+		return Rate{}, errors.Errorf("invalid rate %q: limit should be a positive integer", value)
+	}
+
+	period := strings.ToLower(strings.TrimSpace(value[separator+1:]))
+	digits := strings.IndexFunc(period, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		return Rate{}, errors.Errorf("invalid rate %q: period should end with a unit", value)
+	}
+
+	multiplier := int64(1)
+	if digits > 0 {
+		multiplier, err = strconv.ParseInt(period[:digits], 10, 64)
+		if err != nil || multiplier <= 0 {
+			return Rate{}, errors.Errorf("invalid rate %q: period multiplier should be a positive integer", value)
+		}
+	}
+
+	for _, unit := range rateUnits {
+		if unit.name == period[digits:] {
+			if multiplier > math.MaxInt64/int64(unit.duration) {
+				return Rate{}, errors.Errorf("invalid rate %q: period is too long", value)
+			}
+			return Rate{
+				Limit:  limit,
+				Period: time.Duration(multiplier) * unit.duration,
+			}, nil
+		}
+	}
+
+	return Rate{}, errors.Errorf("invalid rate %q: unknown period unit %q", value, period[digits:])
+}
+
+// String formats the limit and the period of the rate as accepted by ParseRate, in the largest unit
+// dividing the period.
+func (rate Rate) String() string {
+	for _, unit := range rateUnits {
+		if rate.Period <= 0 || rate.Period%unit.duration != 0 {
+			continue
+		}
+
+		multiplier := rate.Period / unit.duration
+		if multiplier == 1 {
+			return fmt.Sprintf("%d/%s", rate.Limit, unit.name)
+		}
+		return fmt.Sprintf("%d/%d%s", rate.Limit, multiplier, unit.name)
+	}
+
+	return fmt.Sprintf("%d/%s", rate.Limit, rate.Period)
+}
+
+// NewCalendarRate returns a rate of limit requests per calendar unit, such as a monthly quota
+// resetting at midnight on the 1st. Its period is the nominal length of the unit, 30 days for a month.
+func NewCalendarRate(limit int64, calendar Calendar, location *time.Location) Rate {
+	periods := map[Calendar]time.Duration{
+		CalendarHour:  time.Hour,
+		CalendarDay:   24 * time.Hour,
+		CalendarMonth: 30 * 24 * time.Hour,
+	}
+
+	return Rate{
+		Limit:    limit,
+		Period:   periods[calendar],
+		Calendar: calendar,
+		Location: location,
+	}
+}
+
+// GetWindowTTL returns how long a fixed window starting at given instant lasts: the period of the
+// rate, or the time until the next calendar boundary if the rate is aligned to the calendar.
+func (rate Rate) GetWindowTTL(now time.Time) time.Duration {
+	if rate.Calendar == "" {
+		return rate.Period
+	}
+	return rate.Calendar.Next(now, rate.Location).Sub(now)
+}
+
+// Next returns the first boundary of the calendar unit after given instant, in given location, or
+// in UTC if location is nil.
+func (calendar Calendar) Next(now time.Time, location *time.Location) time.Time {
+	if location == nil {
+		location = time.UTC
+	}
+
+	now = now.In(location)
+	year, month, day := now.Date()
+
+	switch calendar {
+	case CalendarHour:
+		return time.Date(year, month, day, now.Hour()+1, 0, 0, 0, location)
+	case CalendarDay:
+		return time.Date(year, month, day+1, 0, 0, 0, 0, location)
+	default:
+		return time.Date(year, month+1, 1, 0, 0, 0, 0, location)
+	}
+}
@@ -0,0 +1,80 @@
+package limiter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+func TestCalendarNext(t *testing.T) {
+	is := require.New(t)
+
+	location, err := time.LoadLocation("America/New_York")
+	is.NoError(err)
+
+	now := time.Date(2024, time.January, 31, 22, 30, 0, 0, time.UTC)
+	is.Equal(time.Date(2024, time.January, 31, 23, 0, 0, 0, time.UTC), limiter.CalendarHour.Next(now, nil))
+	is.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), limiter.CalendarDay.Next(now, nil))
+	is.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC), limiter.CalendarMonth.Next(now, time.UTC))
+
+	// In New York, it is still 17:30 on January 31.
+	is.Equal(time.Date(2024, time.February, 1, 0, 0, 0, 0, location), limiter.CalendarDay.Next(now, location))
+
+	// December rolls over to January of the next year.
+	now = time.Date(2024, time.December, 15, 0, 0, 0, 0, time.UTC)
+	is.Equal(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), limiter.CalendarMonth.Next(now, nil))
+
+	// The day of a daylight saving time change lasts 23 hours.
+	now = time.Date(2024, time.March, 10, 0, 0, 0, 0, location)
+	is.Equal(23*time.Hour, limiter.NewCalendarRate(10, limiter.CalendarDay, location).GetWindowTTL(now))
+}
+
+func TestRateGetWindowTTL(t *testing.T) {
+	is := require.New(t)
+
+	now := time.Date(2024, time.May, 20, 10, 15, 0, 0, time.UTC)
+	is.Equal(time.Minute, limiter.NewRate(10, 60).GetWindowTTL(now))
+	is.Equal(45*time.Minute, limiter.NewCalendarRate(10, limiter.CalendarHour, nil).GetWindowTTL(now))
+	is.Equal(30*24*time.Hour, limiter.NewCalendarRate(10, limiter.CalendarMonth, nil).Period)
+}
+
+func TestParseRate(t *testing.T) {
+	is := require.New(t)
+
+	expected := map[string]limiter.Rate{
+		"10/s":     {Limit: 10, Period: time.Second},
+		"100-M":    {Limit: 100, Period: time.Minute},
+		"100/m":    {Limit: 100, Period: time.Minute},
+		"5000/h":   {Limit: 5000, Period: time.Hour},
+		"1000-D":   {Limit: 1000, Period: 24 * time.Hour},
+		"500/30s":  {Limit: 500, Period: 30 * time.Second},
+		"5/250ms":  {Limit: 5, Period: 250 * time.Millisecond},
+		" 20 / 2m": {Limit: 20, Period: 2 * time.Minute},
+	}
+	for value, rate := range expected {
+		parsed, err := limiter.ParseRate(value)
+		is.NoError(err, value)
+		is.Equal(rate, parsed, value)
+	}
+
+	for _, value := range []string{"", "10", "10/", "0/s", "-1/s", "a/s", "10/0s", "10/30", "10/w", "10/s2",
+		"1/9999999999999d", "1/9223372036854775807ms"} {
+		_, err := limiter.ParseRate(value)
+		is.Error(err, value)
+	}
+}
+
+func TestRateString(t *testing.T) {
+	is := require.New(t)
+
+	for _, value := range []string{"10/s", "100/m", "5000/h", "1000/d", "500/30s", "5/250ms", "20/90m"} {
+		rate, err := limiter.ParseRate(value)
+		is.NoError(err)
+		is.Equal(value, rate.String())
+	}
+
+	is.Equal("10/1.5µs", limiter.Rate{Limit: 10, Period: 1500 * time.Nanosecond}.String())
+}
@@ -0,0 +1,108 @@
+package limiter
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// DefaultScanCount is the number of keys requested per batch when every key of a store is listed.
+const DefaultScanCount = 1000
+
+// KeyState is the usage of a key held by a store.
+type KeyState struct {
+	Key string
+	// Count is the number of requests counted in the current window of the key. With several rates,
+	// it is the highest count among them.
+	Count int64
+	// TTL is how long the state of the key is kept.
+	TTL time.Duration
+	// BlockedFor is how long the key remains blocked, or zero.
+	BlockedFor time.Duration
+}
+
+// Merge combines the states of a key returned by several batches, or for several rates.
+func (state KeyState) Merge(other KeyState) KeyState {
+	if other.Count > state.Count {
+		state.Count = other.Count
+	}
+	if other.TTL > state.TTL {
+		state.TTL = other.TTL
+	}
+	if other.BlockedFor > state.BlockedFor {
+		state.BlockedFor = other.BlockedFor
+	}
+	return state
+}
+
+// ScanAll lists every key of the store, keeping those for which keep returns true.
+func ScanAll(ctx context.Context, store ScanStore, keep func(state KeyState) bool) ([]KeyState, error) {
+	states := make(map[string]KeyState)
+	cursor := uint64(0)
+	for {
+		batch, next, err := store.Scan(ctx, cursor, DefaultScanCount)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, state := range batch {
+			if previous, ok := states[state.Key]; ok {
+				state = previous.Merge(state)
+			}
+			states[state.Key] = state
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	result := make([]KeyState, 0, len(states))
+	for _, state := range states {
+		if keep == nil || keep(state) {
+			result = append(result, state)
+		}
+	}
+	return result, nil
+}
+
+// TopKeys returns the n keys of the store with the highest count, from the highest.
+func TopKeys(ctx context.Context, store ScanStore, n int) ([]KeyState, error) {
+	states, err := ScanAll(ctx, store, func(state KeyState) bool {
+		return state.Count > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].Count != states[j].Count {
+			return states[i].Count > states[j].Count
+		}
+		return states[i].Key < states[j].Key
+	})
+
+	if n >= 0 && len(states) > n {
+		states = states[:n]
+	}
+	return states, nil
+}
+
+// BlockedKeys returns the keys of the store which are currently blocked, from the longest blocked.
+func BlockedKeys(ctx context.Context, store ScanStore) ([]KeyState, error) {
+	states, err := ScanAll(ctx, store, func(state KeyState) bool {
+		return state.BlockedFor > 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].BlockedFor != states[j].BlockedFor {
+			return states[i].BlockedFor > states[j].BlockedFor
+		}
+		return states[i].Key < states[j].Key
+	})
+	return states, nil
+}
@@ -0,0 +1,61 @@
+package limiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	limiter "github.com/hgtpcastro/go-expert-lab-rate-limiter"
+)
+
+// pagedStore returns its states in batches of the requested size, the cursor being the offset of the
+// next batch.
+type pagedStore struct {
+	states []limiter.KeyState
+}
+
+func (store *pagedStore) Scan(ctx context.Context, cursor uint64, count int64) ([]limiter.KeyState, uint64, error) {
+	end := cursor + uint64(count)
+	if end >= uint64(len(store.states)) {
+		return store.states[cursor:], 0, nil
+	}
+	return store.states[cursor:end], end, nil
+}
+
+func TestTopKeysAndBlockedKeys(t *testing.T) {
+	is := require.New(t)
+	ctx := context.Background()
+
+	states := []limiter.KeyState{
+		{Key: "ip:a", Count: 3, TTL: time.Minute},
+		{Key: "ip:b", Count: 10, TTL: time.Minute},
+		{Key: "ip:c", BlockedFor: time.Minute},
+		{Key: "ip:d", Count: 5, TTL: time.Minute, BlockedFor: time.Hour},
+	}
+
+	// The states of a key may be split across batches.
+	for i := 0; i < 2*limiter.DefaultScanCount; i++ {
+		states = append(states, limiter.KeyState{Key: "ip:c", Count: 7})
+	}
+	store := &pagedStore{states: states}
+
+	all, err := limiter.ScanAll(ctx, store, nil)
+	is.NoError(err)
+	is.Len(all, 4)
+
+	top, err := limiter.TopKeys(ctx, store, 3)
+	is.NoError(err)
+	is.Equal([]limiter.KeyState{
+		{Key: "ip:b", Count: 10, TTL: time.Minute},
+		{Key: "ip:c", Count: 7, BlockedFor: time.Minute},
+		{Key: "ip:d", Count: 5, TTL: time.Minute, BlockedFor: time.Hour},
+	}, top)
+
+	blocked, err := limiter.BlockedKeys(ctx, store)
+	is.NoError(err)
+	is.Len(blocked, 2)
+	is.Equal("ip:d", blocked[0].Key)
+	is.Equal("ip:c", blocked[1].Key)
+}
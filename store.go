@@ -1,6 +1,29 @@
 package limiter
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	DefaultPrefix          = "limiter"
+	DefaultCleanUpInterval = 30 * time.Second
+	DefaultMaxLogSize      = 10000
+)
+
+var (
+	// ErrStoreUnavailable is returned by stores which are known to be failing, without calling them.
+	ErrStoreUnavailable = errors.New("limiter: store unavailable")
+	// ErrMultipleRatesUnsupported is returned when several rates are used with a store which is not a MultiStore.
+	ErrMultipleRatesUnsupported = errors.New("limiter: store does not support multiple rates")
+	// ErrInvalidRate wraps the errors of rates which a store can't apply, such as a sliding log limit
+	// exceeding the maximum log size.
+	ErrInvalidRate = errors.New("limiter: invalid rate")
+	// ErrScanUnsupported is returned when the keys of a store which is not a ScanStore are listed.
+	ErrScanUnsupported = errors.New("limiter: store does not support listing keys")
+)
 
 type Store interface {
 	Get(ctx context.Context, key string, rate Rate) (Context, error)
@@ -9,6 +32,27 @@ type Store interface {
 	Inc(ctx context.Context, key string, count int64, rate Rate) (Context, error)
 }
 
+// MultiStore is implemented by stores applying several rates to a key atomically. The rates must
+// use the fixed window algorithm, without block.
+type MultiStore interface {
+	// IncMulti increments the counters of the key for every rate by count, only if none of them
+	// would exceed its limit. It returns the context of the most restrictive rate.
+	IncMulti(ctx context.Context, key string, count int64, rates []Rate) (Context, error)
+	// PeekMulti returns the context of the most restrictive rate, which is reached once the next
+	// request would be rejected.
+	PeekMulti(ctx context.Context, key string, rates []Rate) (Context, error)
+}
+
+// ScanStore is implemented by stores listing the keys they hold.
+type ScanStore interface {
+	// Scan returns a batch of about count keys from the cursor, and the cursor of the next batch, which
+	// is zero once every key was returned. A key may be returned in several batches.
+	Scan(ctx context.Context, cursor uint64, count int64) ([]KeyState, uint64, error)
+}
+
 type StoreOptions struct {
-	Prefix string
+	Prefix          string
+	CleanUpInterval time.Duration
+	// MaxLogSize caps the number of timestamps kept per key by the sliding log algorithm.
+	MaxLogSize int64
 }
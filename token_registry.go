@@ -0,0 +1,9 @@
+package limiter
+
+import "context"
+
+// TokenRegistry maps API tokens to their own rate, so tokens of different tiers get different limits.
+type TokenRegistry interface {
+	// Get returns the rate of given token, and false if the token has no custom rate.
+	Get(ctx context.Context, token string) (Rate, bool, error)
+}